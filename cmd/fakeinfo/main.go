@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"github.com/jaam8/online_song_library/pkg/fakeinfo"
+	"github.com/jaam8/online_song_library/pkg/logger"
+	"go.uber.org/zap"
+	"log"
+	"net/http"
+	"time"
+)
+
+// fakeinfo поднимает локальную реализацию Music info API (GET /info) по фикстурам
+func main() {
+	addr := flag.String("addr", ":8081", "listen address")
+	fixturesPath := flag.String("fixtures", "cmd/fakeinfo/fixtures.json", "path to json or yaml fixtures")
+	mode := flag.String("mode", string(fakeinfo.ModeOK), "response mode: ok, bad_request, error, malformed")
+	latency := flag.Duration("latency", 0, "delay before every response")
+	failureRate := flag.Float64("failure-rate", 0, "share of requests answered with 500 in ok mode")
+	flag.Parse()
+
+	logg, err := logger.New("debug")
+	if err != nil {
+		log.Fatalf("failed to create logger: %v", err)
+	}
+
+	m, err := fakeinfo.ParseMode(*mode)
+	if err != nil {
+		logg.Fatal("invalid mode", zap.Error(err))
+	}
+	fixtures, err := fakeinfo.LoadFixtures(*fixturesPath)
+	if err != nil {
+		logg.Fatal("failed to load fixtures", zap.Error(err))
+	}
+
+	s := fakeinfo.New(fixtures, fakeinfo.Options{
+		Mode:        m,
+		Latency:     *latency,
+		FailureRate: *failureRate,
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/info", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		s.ServeHTTP(w, r)
+		logg.Info("Request",
+			zap.String("path", r.URL.Path),
+			zap.String("query", r.URL.RawQuery),
+			zap.Duration("duration", time.Since(start)))
+	}))
+
+	logg.Info("fake music info server starting",
+		zap.String("addr", *addr),
+		zap.String("mode", string(m)),
+		zap.Int("fixtures", len(fixtures)))
+	if err = http.ListenAndServe(*addr, mux); err != nil {
+		logg.Fatal("server error", zap.Error(err))
+	}
+}
@@ -2,22 +2,30 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	_ "github.com/jaam8/online_song_library/docs"
 	"github.com/jaam8/online_song_library/internal/api"
 	"github.com/jaam8/online_song_library/internal/config"
+	"github.com/jaam8/online_song_library/internal/metrics"
 	"github.com/jaam8/online_song_library/internal/repository"
 	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/jaam8/online_song_library/internal/tracing"
 	"github.com/jaam8/online_song_library/pkg/logger"
 	"github.com/jaam8/online_song_library/pkg/postgres"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	echoSwagger "github.com/swaggo/echo-swagger"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 	"log"
+	"math"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -32,39 +40,203 @@ func main() {
 
 	logg, _ := logger.New(cfg.LogLevel)
 
-	db, err := postgres.New(cfg.Postgres)
+	shutdownTracing, err := tracing.New(ctx, cfg.Tracing)
 	if err != nil {
-		logg.Fatal("failed to connect to database", zap.Error(err))
+		logg.Fatal("failed to configure tracing", zap.Error(err))
 	}
-	logg.Info("connected to database", zap.String("host", cfg.Postgres.Host))
+	logg.Info("tracing configured", zap.String("exporter", cfg.Tracing.Exporter))
 
-	r := repository.New(db, logg)
-	s := service.New(r, logg, cfg.SwaggerUrl)
+	var (
+		db *gorm.DB
+		r  repository.Store
+	)
+	switch cfg.Storage {
+	case repository.StoragePostgres:
+		db, err = postgres.New(cfg.Postgres)
+		if err != nil {
+			logg.Fatal("failed to connect to database", zap.Error(err))
+		}
+		logg.Info("connected to database", zap.String("host", cfg.Postgres.Host))
+		if err = db.Use(metrics.GormPlugin{}); err != nil {
+			logg.Fatal("failed to register gorm metrics", zap.Error(err))
+		}
+		if err = db.Use(tracing.GormPlugin{}); err != nil {
+			logg.Fatal("failed to register gorm tracing", zap.Error(err))
+		}
+		r = repository.New(db, logg)
+	case repository.StorageMemory:
+		logg.Warn("using in-memory storage, songs will be lost on restart")
+		r = repository.NewMemory(logg)
+	default:
+		logg.Fatal("unknown storage", zap.String("storage", cfg.Storage))
+	}
+
+	metrics.RegisterSongsGauge(func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Health.CheckTimeout)
+		defer cancel()
+		count, err := r.CountSongs(ctx)
+		if err != nil {
+			logg.Error("failed to count songs for metrics", zap.Error(err))
+			return math.NaN()
+		}
+		return float64(count)
+	})
+
+	chain, err := service.NewProviders(cfg.Providers, cfg.Swagger, logg)
+	if err != nil {
+		logg.Fatal("failed to configure info providers", zap.Error(err))
+	}
+	var p service.SongInfoProvider = chain
+	logg.Info("info providers configured", zap.Strings("providers", cfg.Providers.Order))
+
+	var cache service.SongInfoCache
+	switch cfg.Cache.Backend {
+	case service.CacheBackendNone:
+	case service.CacheBackendMemory:
+		cache = service.NewMemoryInfoCache(cfg.Cache.Size)
+	case service.CacheBackendPostgres:
+		if db == nil {
+			logg.Fatal("postgres cache backend requires postgres storage")
+		}
+		cache = repository.NewSongInfoCache(db, logg)
+	default:
+		logg.Fatal("unknown cache backend", zap.String("backend", cfg.Cache.Backend))
+	}
+	if cache != nil {
+		p = service.NewCachedProvider(p, cache, cfg.Cache, logg)
+	}
+	logg.Info("song info cache configured", zap.String("backend", cfg.Cache.Backend))
+
+	// воркеры живут дольше ctx сигналов: при остановке они дообрабатывают очередь, а workersCtx отменяется,
+	// только если на это не хватило SHUTDOWN_GRACE_PERIOD
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	q := service.NewEnrichmentQueue(cfg.Enrichment, logg)
+	s := service.New(r, p, cache, q, cfg.Search, logg)
+	if err = s.StartEnrichment(workersCtx); err != nil {
+		logg.Fatal("failed to start enrichment", zap.Error(err))
+	}
+	q.Go(func(ctx context.Context) {
+		if err := s.BackfillLyrics(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			logg.Error("failed to backfill lyrics", zap.Error(err))
+		}
+	})
 	h := api.New(s, logg)
+	health := api.NewHealthHandler(cfg.Health, logg)
+	if db != nil {
+		expected, err := postgres.LatestMigrationVersion(cfg.Postgres.PathToMigrations)
+		if err != nil {
+			logg.Fatal("failed to read migrations", zap.Error(err))
+		}
+		health.AddCheck("postgres", true, func(ctx context.Context) error {
+			return postgres.Ping(ctx, db)
+		})
+		health.AddCheck("migrations", true, func(ctx context.Context) error {
+			return postgres.CheckMigrationVersion(ctx, db, expected)
+		})
+	}
+	health.AddCheck("info", cfg.Health.CheckInfo, chain.Ping)
 
 	e := echo.New()
+	e.HTTPErrorHandler = api.HTTPErrorHandler(logg)
+	e.Use(api.RequestIDMiddleware(logg))
 	e.Use(api.LoggingMiddleware(logg))
+	e.Use(api.MetricsMiddleware())
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.OPTIONS},
-		AllowHeaders: []string{"Authorization", "Content-Type"},
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.OPTIONS},
+		AllowHeaders:  []string{"Authorization", "Content-Type", echo.HeaderXRequestID, "traceparent", "X-Author", "X-Change-Reason"},
+		ExposeHeaders: []string{echo.HeaderXRequestID},
 	}))
 
-	e.GET("/api/v1/songs", h.GetAllSongsHandler)
-	e.POST("/api/v1/songs", h.CreateSongHandler)
-	e.GET("/api/v1/songs/:id", h.GetSongHandler)
-	e.PUT("/api/v1/songs/:id", h.UpdateSongHandler)
-	e.DELETE("/api/v1/songs/:id", h.DeleteSongHandler)
+	e.GET("/api/v1/songs", h.GetAllSongsHandler, api.TimeoutMiddleware(cfg.Timeouts.List))
+	e.POST("/api/v1/songs", h.CreateSongHandler, api.TimeoutMiddleware(cfg.Timeouts.Create))
+	e.GET("/api/v1/songs/search", h.SearchSongsHandler, api.TimeoutMiddleware(cfg.Timeouts.List))
+	e.GET("/api/v1/songs/:id", h.GetSongHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.PUT("/api/v1/songs/:id", h.UpdateSongHandler, api.TimeoutMiddleware(cfg.Timeouts.Update))
+	e.DELETE("/api/v1/songs/:id", h.DeleteSongHandler, api.TimeoutMiddleware(cfg.Timeouts.Delete))
+	e.GET("/api/v1/songs/:id/enrichment", h.GetEnrichmentStatusHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.GET("/api/v1/songs/:id/lyrics", h.GetLyricsHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.PUT("/api/v1/songs/:id/lyrics/synced", h.PutSyncedLyricsHandler, api.TimeoutMiddleware(cfg.Timeouts.Update))
+	e.GET("/api/v1/songs/:id/lyrics/synced", h.GetSyncedLyricsHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.GET("/api/v1/songs/:id/lyrics/synced/at", h.GetSyncedLineHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.GET("/api/v1/songs/:id/translations", h.ListTranslationsHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.GET("/api/v1/songs/:id/translations/:lang", h.GetTranslationHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.PUT("/api/v1/songs/:id/translations/:lang", h.PutTranslationHandler, api.TimeoutMiddleware(cfg.Timeouts.Update))
+	e.DELETE("/api/v1/songs/:id/translations/:lang", h.DeleteTranslationHandler, api.TimeoutMiddleware(cfg.Timeouts.Delete))
+	e.GET("/api/v1/songs/:id/revisions", h.ListRevisionsHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.GET("/api/v1/songs/:id/revisions/diff", h.DiffRevisionsHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.GET("/api/v1/songs/:id/revisions/:rev", h.GetRevisionHandler, api.TimeoutMiddleware(cfg.Timeouts.Get))
+	e.POST("/api/v1/songs/:id/revisions/:rev/restore", h.RestoreRevisionHandler, api.TimeoutMiddleware(cfg.Timeouts.Update))
+	e.POST("/api/v1/songs/:id/refresh", h.RefreshSongHandler, api.TimeoutMiddleware(cfg.Timeouts.Refresh))
+	e.POST("/api/v1/songs/refresh", h.RefreshStaleSongsHandler, api.TimeoutMiddleware(cfg.Timeouts.Refresh))
+	e.DELETE("/api/v1/songs/cache", h.PurgeCacheHandler, api.TimeoutMiddleware(cfg.Timeouts.Admin))
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	e.GET("/livez", health.LivezHandler)
+	e.GET("/readyz", health.ReadyzHandler)
+	e.GET("/health", health.HealthHandler)
 
 	go func() {
 		logg.Info(fmt.Sprintf("server starting on port :%s", cfg.RestPort))
-		if err = e.Start(":" + cfg.RestPort); err != nil {
+		if err := e.Start(":" + cfg.RestPort); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logg.Fatal("server error", zap.Error(err))
 		}
-		logg.Info(fmt.Sprintf("server run on port :%s", cfg.RestPort))
 	}()
+	health.SetReady(true)
 
 	<-ctx.Done()
+	stop()
+	logg.Info("shutting down server", zap.Duration("grace_period", cfg.Shutdown.GracePeriod))
+	shutdown(cfg.Shutdown, logg, health, e, q, stopWorkers, db)
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown.GracePeriod)
+	if err = shutdownTracing(flushCtx); err != nil {
+		logg.Error("failed to flush traces", zap.Error(err))
+	}
+	cancel()
 	logg.Info("server stopped")
+	_ = logg.Sync()
+}
+
+// shutdown останавливает сервис по порядку: снимает готовность, дожидается завершения текущих запросов,
+// дает фоновым воркерам дообработать очередь и закрывает подключение к БД
+func shutdown(cfg config.ShutdownConfig, logg *zap.Logger, health *api.HealthHandler, e *echo.Echo,
+	q *service.EnrichmentQueue, stopWorkers context.CancelFunc, db *gorm.DB) {
+	health.SetReady(false)
+	time.Sleep(cfg.ReadinessDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GracePeriod)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		logg.Error("failed to drain http server", zap.Error(err))
+	} else {
+		logg.Info("http server drained")
+	}
+
+	q.Stop()
+	done := make(chan struct{})
+	go func() {
+		q.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// незавершенные задачи прерываются, их песни остаются в pending до следующего запуска
+		logg.Warn("enrichment queue was not drained within grace period, canceling jobs")
+		stopWorkers()
+		<-done
+	}
+
+	if db != nil {
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil {
+			logg.Error("failed to close database", zap.Error(err))
+		} else {
+			logg.Info("database connection closed")
+		}
+	}
 }
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS songs_enrichment_pending_idx;
+
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS enrichment_status,
+    DROP COLUMN IF EXISTS enrichment_attempts,
+    DROP COLUMN IF EXISTS enrichment_error;
@@ -0,0 +1,7 @@
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS enrichment_status TEXT NOT NULL DEFAULT 'enriched',
+    ADD COLUMN IF NOT EXISTS enrichment_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS enrichment_error TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS songs_enrichment_pending_idx
+    ON songs (id) WHERE enrichment_status = 'pending';
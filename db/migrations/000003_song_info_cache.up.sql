@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS song_info_cache (
+    "group" TEXT NOT NULL,
+    song TEXT NOT NULL,
+    release_date DATE,
+    text TEXT,
+    link TEXT,
+    not_found BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY ("group", song)
+);
+
+CREATE INDEX IF NOT EXISTS song_info_cache_expires_at_idx ON song_info_cache (expires_at);
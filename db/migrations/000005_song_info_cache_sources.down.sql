@@ -0,0 +1,4 @@
+ALTER TABLE song_info_cache
+    DROP COLUMN IF EXISTS release_date_source,
+    DROP COLUMN IF EXISTS text_source,
+    DROP COLUMN IF EXISTS link_source;
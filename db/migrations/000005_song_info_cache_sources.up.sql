@@ -0,0 +1,4 @@
+ALTER TABLE song_info_cache
+    ADD COLUMN IF NOT EXISTS release_date_source TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS text_source TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS link_source TEXT NOT NULL DEFAULT '';
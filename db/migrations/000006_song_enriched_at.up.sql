@@ -0,0 +1,4 @@
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS enriched_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS songs_enriched_at_idx ON songs (enriched_at);
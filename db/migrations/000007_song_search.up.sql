@@ -0,0 +1,14 @@
+-- Вектор строится сразу для нескольких конфигураций: simple находит слова без учета морфологии,
+-- english и russian — словоформы; конфигурация запроса выбирает, какие лексемы совпадут.
+-- Название группы и песни весят больше текста.
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple'::regconfig, "group" || ' ' || song), 'A') ||
+        setweight(to_tsvector('simple'::regconfig, text), 'B') ||
+        setweight(to_tsvector('english'::regconfig, "group" || ' ' || song), 'A') ||
+        setweight(to_tsvector('english'::regconfig, text), 'B') ||
+        setweight(to_tsvector('russian'::regconfig, "group" || ' ' || song), 'A') ||
+        setweight(to_tsvector('russian'::regconfig, text), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS songs_search_vector_idx ON songs USING GIN (search_vector);
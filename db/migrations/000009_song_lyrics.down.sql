@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS songs_lyrics_missing_idx;
+
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS lyrics;
@@ -0,0 +1,5 @@
+-- Структурированный текст заполняется приложением при запуске, см. SongService.BackfillLyrics
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS lyrics JSONB;
+
+CREATE INDEX IF NOT EXISTS songs_lyrics_missing_idx ON songs (id) WHERE lyrics IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS synced_lyrics JSONB;
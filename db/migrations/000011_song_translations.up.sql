@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS song_translations (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    language TEXT NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, language)
+);
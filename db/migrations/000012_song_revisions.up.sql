@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS song_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    author TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    "group" TEXT NOT NULL,
+    song TEXT NOT NULL,
+    release_date DATE NOT NULL,
+    text TEXT NOT NULL,
+    link TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS song_revisions_song_id_idx ON song_revisions (song_id, id);
+
+-- Текущее состояние существующих песен становится их первой ревизией, чтобы первое изменение не потеряло старый текст
+INSERT INTO song_revisions (song_id, author, reason, "group", song, release_date, text, link)
+SELECT id, 'system', 'initial revision', "group", song, release_date, text, link
+FROM songs;
@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/": {
             "get": {
-                "description": "Получение всех песен с фильтрацией и пагинацией",
+                "description": "Получение всех песен с фильтрацией и пагинацией.\nНесколько значений через запятую объединяются через OR (group=Muse,Queen), запятую в значении можно экранировать как \\,.\nСуффикс _like — вхождение без учета регистра, _from и _to — границы даты включительно.\n! в конце имени параметра инвертирует условие: group!=Muse, song_like!=live.\nsort=field[,-field] сортирует по id, group, song и release_date; при равенстве песни упорядочиваются по id.\nПагинация курсором включается параметрами limit или cursor: ответ содержит cursor.next и cursor.prev,\nкоторые передаются в cursor без изменений вместе с теми же фильтрами. Курсор помнит сортировку, sort можно не повторять.\nОбщее число песен в этом режиме считается только при total=true. page и per_page с курсором не сочетаются",
                 "consumes": [
                     "application/json"
                 ],
@@ -31,34 +31,77 @@ const docTemplate = `{
                 "parameters": [
                     {
                         "type": "string",
-                        "description": " ",
+                        "description": "точное совпадение, через запятую — любое из значений",
                         "name": "group",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": " ",
+                        "description": "вхождение в название группы без учета регистра",
+                        "name": "group_like",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "точное совпадение, через запятую — любое из значений",
                         "name": "song",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": " ",
+                        "description": "вхождение в название песни без учета регистра",
+                        "name": "song_like",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "дата выхода DD.MM.YYYY",
                         "name": "release_date",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": " ",
+                        "description": "дата выхода не раньше DD.MM.YYYY",
+                        "name": "release_date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "дата выхода не позже DD.MM.YYYY",
+                        "name": "release_date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "точное совпадение текста",
                         "name": "text",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": " ",
+                        "description": "вхождение в текст без учета регистра",
+                        "name": "text_like",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "точное совпадение ссылки",
                         "name": "link",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "description": "вхождение в ссылку без учета регистра",
+                        "name": "link_like",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "-release_date,group",
+                        "description": "поля сортировки через запятую: id, group, song, release_date; минус — по убыванию",
+                        "name": "sort",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
                         "default": 1,
@@ -72,6 +115,26 @@ const docTemplate = `{
                         "description": " ",
                         "name": "per_page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "курсор из cursor.next или cursor.prev предыдущего ответа",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "размер страницы в режиме курсора",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "посчитать общее число песен в режиме курсора",
+                        "name": "total",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -88,7 +151,7 @@ const docTemplate = `{
                         }
                     },
                     "422": {
-                        "description": "invalid release_date\" example:{\"error\": \"invalid release_date\"}",
+                        "description": "invalid sort\" example:{\"error\": \"invalid sort: unknown field text\"}",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -98,11 +161,17 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             },
             "post": {
-                "description": "Добавляет новую песню, получая информацию о ней через запрос к стороннему API, возвращает ID песни",
+                "description": "Добавляет новую песню, получая информацию о ней через запрос к стороннему API, возвращает ID песни.\nЕсли release_date, text и link переданы в запросе, сторонний API не вызывается;\nпереданные поля сохраняются с источником manual.\nС async=true песня сохраняется в состоянии pending и обогащается в фоне",
                 "consumes": [
                     "application/json"
                 ],
@@ -115,13 +184,34 @@ const docTemplate = `{
                 "summary": "Добавление новой песни",
                 "parameters": [
                     {
-                        "description": "название группы и песни",
+                        "description": "название группы и песни, опционально release_date (DD.MM.YYYY), text и link",
                         "name": "song",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/api.CreateSongHandler.request"
+                            "$ref": "#/definitions/models.SongRaw"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "обогатить песню в фоне",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "anonymous",
+                        "description": "автор изменения для истории ревизий",
+                        "name": "X-Author",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "default": "created",
+                        "description": "причина изменения",
+                        "name": "X-Change-Reason",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -131,6 +221,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.CreateSongHandler.successResponse"
                         }
                     },
+                    "202": {
+                        "description": "accepted for enrichment\" example:{\"id\": 1, \"enrichment_status\": \"pending\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateSongHandler.acceptedResponse"
+                        }
+                    },
                     "400": {
                         "description": "invalid request\" example:{\"error\": \"invalid request\"}",
                         "schema": {
@@ -144,7 +240,7 @@ const docTemplate = `{
                         }
                     },
                     "422": {
-                        "description": "all field are required\" example:{\"error\": \"all field are required\"}",
+                        "description": "invalid release_date\" example:{\"error\": \"invalid release_date\"}",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -154,13 +250,37 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "502": {
+                        "description": "bad upstream payload\" example:{\"error\": \"bad upstream payload: missing required fields releaseDate\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "music info service unavailable\" example:{\"error\": \"music info service unavailable\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "seconds until the music info service is retried"
+                            }
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/{id}": {
-            "get": {
-                "description": "Получение песни и пагинация текста по куплетам",
+        "/cache": {
+            "delete": {
+                "description": "Удаляет закешированный ответ стороннего API для пары group и song, без параметров очищает весь кеш",
                 "consumes": [
                     "application/json"
                 ],
@@ -168,110 +288,154 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "admin"
                 ],
-                "summary": "Получение песни и пагинация текста",
+                "summary": "Очистка кеша ответов API",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "song id",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "page number",
-                        "name": "page",
+                        "type": "string",
+                        "description": "название группы",
+                        "name": "group",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "default": 5,
-                        "description": "items per page",
-                        "name": "per_page",
+                        "type": "string",
+                        "description": "название песни",
+                        "name": "song",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "received successfully",
+                        "description": "purged successfully\" example:{\"purged\": 1}",
                         "schema": {
-                            "$ref": "#/definitions/api.GetSongHandler.successResponse"
+                            "$ref": "#/definitions/api.PurgeCacheHandler.successResponse"
                         }
                     },
-                    "404": {
-                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                    "422": {
+                        "description": "group and song are required together\" example:{\"error\": \"group and song are required together\"}",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "422": {
-                        "description": "invalid per_page\" example:{\"error\": \"invalid per_page\"}",
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновление песни по ID",
-                "consumes": [
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Возвращает статус и время ответа каждой зависимости.\nok — все проверки прошли, degraded — упали только необязательные, down — упала обязательная (503)",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Состояние зависимостей",
+                "responses": {
+                    "200": {
+                        "description": "ok или degraded",
+                        "schema": {
+                            "$ref": "#/definitions/api.healthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "down",
+                        "schema": {
+                            "$ref": "#/definitions/api.healthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/livez": {
+            "get": {
+                "description": "Возвращает 200, пока процесс запущен и обрабатывает запросы; зависимости не проверяются",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "songs"
+                    "health"
                 ],
-                "summary": "Обновление песни",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "song id",
-                        "name": "id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "song update data",
-                        "name": "song",
-                        "in": "body",
-                        "required": true,
+                "summary": "Живость сервиса",
+                "responses": {
+                    "200": {
+                        "description": "alive\" example:{\"status\": \"alive\"}",
                         "schema": {
-                            "$ref": "#/definitions/models.SongRaw"
+                            "$ref": "#/definitions/api.statusResponse"
                         }
                     }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Возвращает 200, если сервис запущен и обязательные зависимости (PostgreSQL, версия миграций,\nпри HEALTH_CHECK_INFO — API /info) доступны, и 503 во время запуска, остановки или при сбое зависимости",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
                 ],
+                "summary": "Готовность сервиса",
                 "responses": {
                     "200": {
-                        "description": "updated successfully\" example:{\"success\": true}",
+                        "description": "ready\" example:{\"status\": \"ready\"}",
                         "schema": {
-                            "$ref": "#/definitions/api.UpdateSongHandler.successResponse"
+                            "$ref": "#/definitions/api.statusResponse"
                         }
                     },
-                    "400": {
-                        "description": "invalid data\" example:{\"error\": \"invalid data\"}",
+                    "503": {
+                        "description": "not ready\" example:{\"status\": \"not ready\"}",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/api.statusResponse"
                         }
-                    },
-                    "404": {
-                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "Ставит в фоновую очередь обновление всех песен, обогащенных раньше чем older_than_days дней назад",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Фоновое обновление устаревших песен",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 30,
+                        "description": "возраст последнего обогащения в днях",
+                        "name": "older_than_days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "scheduled for refresh\" example:{\"queued\": 10}",
                         "schema": {
-                            "$ref": "#/definitions/api.ErrorResponse"
+                            "$ref": "#/definitions/api.RefreshStaleSongsHandler.acceptedResponse"
                         }
                     },
                     "422": {
-                        "description": "all fields are required\" example:{\"error\": \"all fields are required\"}",
+                        "description": "invalid older_than_days\" example:{\"error\": \"invalid older_than_days\"}",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -281,11 +445,19 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаление песни по ID",
+            }
+        },
+        "/search": {
+            "get": {
+                "description": "Ищет песни по группе, названию и тексту и сортирует их по релевантности; совпадения в названии весят больше.\nq в синтаксисе websearch_to_tsquery: слова, \"фраза в кавычках\", -исключение, or.\nhighlights содержит строки текста с совпадениями, выделенными тегами \u003cmark\u003e.\nlang — конфигурация поиска (simple, english, russian), по умолчанию SEARCH_LANGUAGE",
                 "consumes": [
                     "application/json"
                 ],
@@ -295,31 +467,52 @@ const docTemplate = `{
                 "tags": [
                     "songs"
                 ],
-                "summary": "Удаление песни",
+                "summary": "Полнотекстовый поиск песен",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "song id",
-                        "name": "id",
+                        "type": "string",
+                        "example": "\"\\\"black hole\\\" -muse\"",
+                        "description": "поисковый запрос",
+                        "name": "q",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "simple, english или russian",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": " ",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": " ",
+                        "name": "per_page",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "deleted successfully\" example:{\"success\": true}",
+                        "description": "found successfully",
                         "schema": {
-                            "$ref": "#/definitions/api.DeleteSongHandler.successResponse"
+                            "$ref": "#/definitions/api.SearchSongsHandler.successResponse"
                         }
                     },
                     "404": {
-                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "description": "songs not found\" example:{\"error\": \"songs not found\"}",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
                     },
                     "422": {
-                        "description": "invalid id\" example:{\"error\": \"invalid id\"}",
+                        "description": "invalid per_page\" example:{\"error\": \"invalid per_page\"}",
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
@@ -329,35 +522,1482 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ErrorResponse"
                         }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.CreateSongHandler.request": {
-            "type": "object",
-            "properties": {
-                "group": {
-                    "type": "string",
-                    "example": "Muse"
-                },
-                "song": {
-                    "type": "string",
-                    "example": "Supermassive Black Hole"
-                }
-            }
-        },
-        "api.CreateSongHandler.successResponse": {
-            "type": "object",
-            "properties": {
-                "id": {
-                    "type": "integer",
-                    "example": 1
-                }
-            }
         },
-        "api.DeleteSongHandler.successResponse": {
+        "/{id}": {
+            "get": {
+                "description": "Получение песни и пагинация текста по куплетам.\nКуплеты разделяются пустыми строками; mode=line разбивает текст на отдельные строки.\nДля каждого элемента возвращаются номер куплета и номера первой и последней строки без учета пустых.\nПеревод выбирается параметром lang или заголовком Accept-Language; тогда verses содержит перевод,\nа original — куплеты оригинала с теми же номерами. Без подходящего перевода отдается оригинал",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Получение песни и пагинация текста",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "stanza",
+                        "description": "stanza или line",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "язык перевода, тег BCP 47",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "ru, en;q=0.8",
+                        "description": "предпочитаемые языки перевода",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "items per page",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.GetSongHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "translation not found\" example:{\"error\": \"translation not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid per_page\" example:{\"error\": \"invalid per_page\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновление песни по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Обновление песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "song update data",
+                        "name": "song",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.SongRaw"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "default": "anonymous",
+                        "description": "автор изменения для истории ревизий",
+                        "name": "X-Author",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "default": "updated",
+                        "description": "причина изменения",
+                        "name": "X-Change-Reason",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "updated successfully\" example:{\"success\": true}",
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateSongHandler.successResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid data\" example:{\"error\": \"invalid data\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid release_date\" example:{\"error\": \"invalid release_date\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаление песни по ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Удаление песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "deleted successfully\" example:{\"success\": true}",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeleteSongHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid id\" example:{\"error\": \"invalid id\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/enrichment": {
+            "get": {
+                "description": "Возвращает состояние фонового обогащения песни данными из стороннего API",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Статус обогащения песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.GetEnrichmentStatusHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid id\" example:{\"error\": \"invalid id\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/lyrics": {
+            "get": {
+                "description": "Возвращает текст песни целиком: format=flat — одной строкой, format=structured — уникальные куплеты\nс типом (verse, chorus, pre_chorus, bridge, intro, outro) и порядок исполнения, в котором повторы\nприпева ссылаются на один сохраненный куплет",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Текст песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "structured",
+                        "description": "flat или structured",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.GetLyricsHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid format\" example:{\"error\": \"invalid format\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/lyrics/synced": {
+            "get": {
+                "description": "Возвращает строки с метками времени: format=json — с концом строки и номером строки в тексте песни,\nformat=lrc — файлом LRC",
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Синхронизированный текст",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "json",
+                        "description": "json или lrc",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.GetSyncedLyricsHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "synced lyrics not found\" example:{\"error\": \"synced lyrics not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid format\" example:{\"error\": \"invalid format\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Принимает текст в формате LRC ([mm:ss.xx]строка) телом запроса и сохраняет метки времени строк.\nСтроки с одной меткой идут по возрастанию времени, строки с несколькими метками встают между ними.\nНарушение порядка или повтор метки — ошибка.\nТег [offset:ms] сдвигает все метки, остальные теги игнорируются.\nКаждая непустая строка должна совпадать со строкой текста песни. Повторная загрузка заменяет предыдущую",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Загрузка синхронизированного текста",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "example": "\"[00:12.34]Ooh baby, don't you know I suffer?\"",
+                        "description": "текст в формате LRC",
+                        "name": "lrc",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "saved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.PutSyncedLyricsHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "lrc is too large\" example:{\"error\": \"lrc is too large\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "lrc does not match the song text\" example:{\"error\": \"synced lyrics do not match the song text\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/lyrics/synced/at": {
+            "get": {
+                "description": "Возвращает строку, которая звучит в момент t, и следующую за ней. До первой строки current пустой,\nпосле последней — next. t задается в секундах (75.5) или длительностью Go (1m15.5s)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Строка в момент времени",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "75.5",
+                        "description": "время от начала трека",
+                        "name": "t",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.GetSyncedLineHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "synced lyrics not found\" example:{\"error\": \"synced lyrics not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid t\" example:{\"error\": \"invalid t\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/refresh": {
+            "post": {
+                "description": "Повторно запрашивает информацию о песне по ее group и song и показывает изменения полей.\nПоля, заданные вручную, не обновляются. С dry_run=true изменения только возвращаются",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "songs"
+                ],
+                "summary": "Обновление песни из внешнего API",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "не сохранять изменения",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "enrichment",
+                        "description": "автор изменения для истории ревизий",
+                        "name": "X-Author",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "default": "refreshed from upstream",
+                        "description": "причина изменения",
+                        "name": "X-Change-Reason",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "refreshed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.RefreshSongHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "song was modified during refresh\" example:{\"error\": \"song was modified during refresh\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid dry_run\" example:{\"error\": \"invalid dry_run\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "music info service error\" example:{\"error\": \"music info service error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "music info service unavailable\" example:{\"error\": \"music info service unavailable\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/revisions": {
+            "get": {
+                "description": "Возвращает ревизии песни от новых к старым. Ревизия — снимок полей песни после изменения\nс автором (заголовок X-Author) и причиной (заголовок X-Change-Reason)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "История изменений песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": " ",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": " ",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.ListRevisionsHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid per_page\" example:{\"error\": \"invalid per_page\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/revisions/diff": {
+            "get": {
+                "description": "Показывает изменения полей и построчный дифф текста между ревизиями from и to.\nБез to ревизия from сравнивается с последней ревизией.\nСтроки diff: equal — без изменений, delete — есть только в from, insert — только в to;\nold_line и new_line — номера строк в тексте from и to",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Сравнение ревизий песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision id",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision id, по умолчанию последняя ревизия",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.RevisionDiff"
+                        }
+                    },
+                    "404": {
+                        "description": "revision not found\" example:{\"error\": \"revision not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid to\" example:{\"error\": \"invalid to\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/revisions/{rev}": {
+            "get": {
+                "description": "Возвращает снимок полей песни в ревизии rev",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Ревизия песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision id",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongRevision"
+                        }
+                    },
+                    "404": {
+                        "description": "revision not found\" example:{\"error\": \"revision not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid rev\" example:{\"error\": \"invalid rev\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/revisions/{rev}/restore": {
+            "post": {
+                "description": "Возвращает песне значения полей из ревизии rev и сохраняет результат новой ревизией.\nВосстановленные поля считаются заданными вручную. Без X-Change-Reason причиной будет \"restored revision N\"",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "revisions"
+                ],
+                "summary": "Восстановление ревизии песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "revision id",
+                        "name": "rev",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "anonymous",
+                        "description": "автор изменения",
+                        "name": "X-Author",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "причина изменения",
+                        "name": "X-Change-Reason",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "restored successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongRevision"
+                        }
+                    },
+                    "404": {
+                        "description": "revision not found\" example:{\"error\": \"revision not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid rev\" example:{\"error\": \"invalid rev\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/translations": {
+            "get": {
+                "description": "Возвращает все переводы текста песни в порядке языков",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Список переводов песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/api.ListTranslationsHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid id\" example:{\"error\": \"invalid id\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}/translations/{lang}": {
+            "get": {
+                "description": "Возвращает перевод текста песни на язык lang",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Перевод песни",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "ru",
+                        "description": "тег языка BCP 47",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "received successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongTranslation"
+                        }
+                    },
+                    "404": {
+                        "description": "translation not found\" example:{\"error\": \"translation not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid lang\" example:{\"error\": \"invalid lang\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Сохраняет перевод текста песни на язык lang. Куплеты разделяются пустыми строками,\nих число должно совпадать с оригиналом, чтобы куплеты перевода и оригинала шли с одинаковыми номерами",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Создание или замена перевода",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "ru",
+                        "description": "тег языка BCP 47",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "текст перевода",
+                        "name": "translation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.PutTranslationHandler.translationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "replaced successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongTranslation"
+                        }
+                    },
+                    "201": {
+                        "description": "created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/models.SongTranslation"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request\" example:{\"error\": \"invalid request\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "song not found\" example:{\"error\": \"song not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "stanza mismatch\" example:{\"error\": \"translation stanzas do not match the original: translation has 3 stanzas, original has 4\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет перевод текста песни на язык lang",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "translations"
+                ],
+                "summary": "Удаление перевода",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "song id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "ru",
+                        "description": "тег языка BCP 47",
+                        "name": "lang",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "deleted successfully\" example:{\"success\": true}",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeleteTranslationHandler.successResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "translation not found\" example:{\"error\": \"translation not found\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid lang\" example:{\"error\": \"invalid lang\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error\" example:{\"error\": \"internal server error\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    },
+                    "504": {
+                        "description": "request timeout\" example:{\"error\": \"request timeout\"}",
+                        "schema": {
+                            "$ref": "#/definitions/api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.CreateSongHandler.acceptedResponse": {
+            "type": "object",
+            "properties": {
+                "enrichment_status": {
+                    "type": "string",
+                    "example": "pending"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.CreateSongHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.DeleteSongHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DeleteTranslationHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "error text"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2a9c1e7b4d4e8a9c0b1d2e3f4a5b6c"
+                }
+            }
+        },
+        "api.GetAllSongsHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "cursor": {
+                    "$ref": "#/definitions/api.cursorPagination"
+                },
+                "pagination": {
+                    "$ref": "#/definitions/api.offsetPagination"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Song"
+                    }
+                }
+            }
+        },
+        "api.GetEnrichmentStatusHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "status": {
+                    "type": "string",
+                    "example": "enriched"
+                }
+            }
+        },
+        "api.GetLyricsHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "example": "structured"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "order": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "verse-1",
+                        "chorus-1",
+                        "verse-2",
+                        "chorus-1"
+                    ]
+                },
+                "sections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.Section"
+                    }
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?\n..."
+                }
+            }
+        },
+        "api.GetSongHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string",
+                    "example": "ru"
+                },
+                "mode": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/lyrics.Mode"
+                        }
+                    ],
+                    "example": "stanza"
+                },
+                "original": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.Stanza"
+                    }
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "verses": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.Stanza"
+                    }
+                }
+            }
+        },
+        "api.GetSyncedLineHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "$ref": "#/definitions/lyrics.SyncedLine"
+                },
+                "next": {
+                    "$ref": "#/definitions/lyrics.SyncedLine"
+                },
+                "t_ms": {
+                    "type": "integer",
+                    "example": 75500
+                }
+            }
+        },
+        "api.GetSyncedLyricsHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.SyncedLine"
+                    }
+                }
+            }
+        },
+        "api.ListRevisionsHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "pagination": {
+                    "$ref": "#/definitions/api.offsetPagination"
+                },
+                "revisions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongRevision"
+                    }
+                }
+            }
+        },
+        "api.ListTranslationsHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "translations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongTranslation"
+                    }
+                }
+            }
+        },
+        "api.PurgeCacheHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "purged": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.PutSyncedLyricsHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "lines": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "api.PutTranslationHandler.translationRequest": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "example": "О, детка, разве ты не знаешь, что я страдаю?\n..."
+                }
+            }
+        },
+        "api.RefreshSongHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "api.RefreshStaleSongsHandler.acceptedResponse": {
+            "type": "object",
+            "properties": {
+                "queued": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "api.SearchSongsHandler.pagination": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "per_page": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "api.SearchSongsHandler.successResponse": {
+            "type": "object",
+            "properties": {
+                "pagination": {
+                    "$ref": "#/definitions/api.SearchSongsHandler.pagination"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SongSearchHit"
+                    }
+                }
+            }
+        },
+        "api.UpdateSongHandler.successResponse": {
             "type": "object",
             "properties": {
                 "success": {
@@ -366,16 +2006,72 @@ const docTemplate = `{
                 }
             }
         },
-        "api.ErrorResponse": {
+        "api.checkResult": {
             "type": "object",
             "properties": {
                 "error": {
                     "type": "string",
-                    "example": "error text"
+                    "example": "connection refused"
+                },
+                "latency_ms": {
+                    "type": "number",
+                    "example": 1.25
+                },
+                "name": {
+                    "type": "string",
+                    "example": "postgres"
+                },
+                "required": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "status": {
+                    "type": "string",
+                    "example": "up"
+                }
+            }
+        },
+        "api.cursorPagination": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "next": {
+                    "type": "string",
+                    "example": "eyJzIjoiaWQiLCJ2IjpbIjEwIl19"
+                },
+                "prev": {
+                    "type": "string",
+                    "example": "eyJzIjoiaWQiLCJ2IjpbIjEiXSwiYiI6dHJ1ZX0"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 100
+                }
+            }
+        },
+        "api.healthResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.checkResult"
+                    }
+                },
+                "ready": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
                 }
             }
         },
-        "api.GetAllSongsHandler.pagination": {
+        "api.offsetPagination": {
             "type": "object",
             "properties": {
                 "page": {
@@ -392,57 +2088,188 @@ const docTemplate = `{
                 }
             }
         },
-        "api.GetAllSongsHandler.successResponse": {
+        "api.statusResponse": {
             "type": "object",
             "properties": {
-                "pagination": {
-                    "$ref": "#/definitions/api.GetAllSongsHandler.pagination"
-                },
-                "songs": {
+                "checks": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Song"
+                        "$ref": "#/definitions/api.checkResult"
                     }
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ready"
                 }
             }
         },
-        "api.GetSongHandler.successResponse": {
+        "lyrics.DiffLine": {
             "type": "object",
             "properties": {
-                "page": {
+                "new_line": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "old_line": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "op": {
+                    "type": "string",
+                    "example": "insert"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Ooh baby, can you hear me moan?"
+                }
+            }
+        },
+        "lyrics.Mode": {
+            "type": "string",
+            "enum": [
+                "stanza",
+                "line"
+            ],
+            "x-enum-varnames": [
+                "ModeStanza",
+                "ModeLine"
+            ]
+        },
+        "lyrics.Section": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "chorus-1"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Supermassive black hole"
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "example": "chorus"
+                }
+            }
+        },
+        "lyrics.Stanza": {
+            "type": "object",
+            "properties": {
+                "first_line": {
                     "type": "integer",
                     "example": 1
                 },
-                "total": {
+                "last_line": {
                     "type": "integer",
-                    "example": 10
+                    "example": 4
                 },
-                "verses": {
+                "lines": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     },
                     "example": [
-                        "[\"ooh baby",
-                        " don't you know i suffer?\"",
-                        " \"ooh baby",
-                        " can you hear me moan?\"]"
+                        "Ooh baby don't you know I suffer?",
+                        "Ooh baby can you hear me moan?"
                     ]
+                },
+                "stanza": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "api.UpdateSongHandler.successResponse": {
+        "lyrics.SyncedLine": {
             "type": "object",
             "properties": {
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "end_ms": {
+                    "type": "integer",
+                    "example": 15800
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "start_ms": {
+                    "type": "integer",
+                    "example": 12340
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?"
+                }
+            }
+        },
+        "models.FieldChange": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "text"
+                },
+                "new": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?"
+                },
+                "old": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "swagger"
+                }
+            }
+        },
+        "models.RevisionDiff": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    }
+                },
+                "from": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/lyrics.DiffLine"
+                    }
+                },
+                "to": {
+                    "type": "integer",
+                    "example": 12
                 }
             }
         },
         "models.Song": {
             "type": "object",
             "properties": {
+                "enriched_at": {
+                    "type": "string",
+                    "example": "2025-03-01T12:00:00Z"
+                },
+                "enrichment_attempts": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "enrichment_error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "enrichment_status": {
+                    "type": "string",
+                    "example": "enriched"
+                },
                 "group": {
                     "type": "string",
                     "example": "Muse"
@@ -463,6 +2290,9 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Supermassive Black Hole"
                 },
+                "sources": {
+                    "$ref": "#/definitions/models.SongSources"
+                },
                 "text": {
                     "type": "string",
                     "example": "Ooh baby, don't you know I suffer?\n..."
@@ -474,19 +2304,172 @@ const docTemplate = `{
             "properties": {
                 "group": {
                     "description": "ID          uint   ` + "`" + `json:\"id\"` + "`" + `",
-                    "type": "string"
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "link": {
+                    "type": "string",
+                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "16.07.2006"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?\n..."
+                }
+            }
+        },
+        "models.SongRevision": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "example": "editor"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-03-01T12:00:00Z"
+                },
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "link": {
+                    "type": "string",
+                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "fix typo in the second verse"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "2006-06-19T00:00:00Z"
+                },
+                "song": {
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?\n..."
+                }
+            }
+        },
+        "models.SongSearchHit": {
+            "type": "object",
+            "properties": {
+                "enriched_at": {
+                    "type": "string",
+                    "example": "2025-03-01T12:00:00Z"
+                },
+                "enrichment_attempts": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "enrichment_error": {
+                    "type": "string",
+                    "example": ""
+                },
+                "enrichment_status": {
+                    "type": "string",
+                    "example": "enriched"
+                },
+                "group": {
+                    "type": "string",
+                    "example": "Muse"
+                },
+                "highlights": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "\u003cmark\u003eSupermassive\u003c/mark\u003e black hole"
+                    ]
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
                 },
                 "link": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "https://www.youtube.com/watch?v=Xsp3_a-PMTw"
+                },
+                "rank": {
+                    "type": "number",
+                    "example": 0.6079
                 },
                 "release_date": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "2006-06-19T00:00:00Z"
                 },
                 "song": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "Supermassive Black Hole"
+                },
+                "sources": {
+                    "$ref": "#/definitions/models.SongSources"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Ooh baby, don't you know I suffer?\n..."
+                }
+            }
+        },
+        "models.SongSources": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "type": "string",
+                    "example": "manual"
+                },
+                "release_date": {
+                    "type": "string",
+                    "example": "swagger"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "fixtures"
+                }
+            }
+        },
+        "models.SongTranslation": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-03-01T12:00:00Z"
+                },
+                "language": {
+                    "type": "string",
+                    "example": "ru"
+                },
+                "song_id": {
+                    "type": "integer",
+                    "example": 1
                 },
                 "text": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "О, детка, разве ты не знаешь, что я страдаю?\n..."
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2025-03-01T12:00:00Z"
                 }
             }
         }
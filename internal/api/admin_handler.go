@@ -0,0 +1,43 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// @Summary Очистка кеша ответов API
+// @Description Удаляет закешированный ответ стороннего API для пары group и song, без параметров очищает весь кеш
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param group query string false "название группы"
+// @Param song query string false "название песни"
+// @Success 200 {object} api.PurgeCacheHandler.successResponse "purged successfully" example:{"purged": 1}
+// @Failure 422 {object} ErrorResponse "group and song are required together" example:{"error": "group and song are required together"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /cache [delete]
+func (h *SongHandler) PurgeCacheHandler(c echo.Context) error {
+	group := c.QueryParam("group")
+	song := c.QueryParam("song")
+	if (group == "") != (song == "") {
+		h.log(c).Debug("validation failed: group and song must be set together")
+		return errorJSON(c, http.StatusUnprocessableEntity, "group and song are required together")
+	}
+	h.log(c).Debug("starting purge cache",
+		zap.String("group", group),
+		zap.String("song", song))
+
+	purged, err := h.service.PurgeSongInfoCache(c.Request().Context(), group, song)
+	if err != nil {
+		h.log(c).Error("failed to purge cache", zap.Error(err))
+		return h.internalError(c, err)
+	}
+
+	type successResponse struct {
+		Purged int64 `json:"purged" example:"1"`
+	}
+	h.log(c).Info("cache purged", zap.Int64("purged", purged))
+	return c.JSON(http.StatusOK, successResponse{purged})
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/repository"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// parseFilters разбирает фильтры списка песен из параметров запроса:
+// field=a,b — равенство или IN, field_like — вхождение без учета регистра,
+// field_from и field_to — границы диапазона включительно, ! в конце имени (field!=a) — отрицание.
+// Параметры, имя которых не сводится к полю фильтрации, игнорируются; допустимость операторов проверяет репозиторий
+func parseFilters(params url.Values) []models.SongFilter {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	filters := make([]models.SongFilter, 0, len(keys))
+	for _, key := range keys {
+		name, negate := strings.CutSuffix(key, "!")
+		op := models.FilterEq
+		if field, ok := strings.CutSuffix(name, "_like"); ok {
+			name, op = field, models.FilterLike
+		} else if field, ok := strings.CutSuffix(name, "_from"); ok {
+			name, op = field, models.FilterGTE
+		} else if field, ok := strings.CutSuffix(name, "_to"); ok {
+			name, op = field, models.FilterLTE
+		}
+		if !repository.IsFilterField(name) {
+			continue
+		}
+
+		values := make([]interface{}, 0)
+		for _, raw := range params[key] {
+			for _, v := range splitList(raw) {
+				if v != "" {
+					values = append(values, v)
+				}
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		filters = append(filters, models.SongFilter{Field: name, Op: op, Values: values, Negate: negate})
+	}
+	return filters
+}
+
+// splitList разбивает значение по запятым; запятую внутри значения можно экранировать как \,
+func splitList(raw string) []string {
+	var (
+		items []string
+		b     strings.Builder
+	)
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw) && raw[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case raw[i] == ',':
+			items = append(items, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(raw[i])
+		}
+	}
+	return append(items, strings.TrimSpace(b.String()))
+}
+
+// parseSort разбирает сортировку вида field[,-field]: минус перед именем — по убыванию.
+// Допустимость полей проверяет репозиторий
+func parseSort(raw string) []models.SortField {
+	var sort []models.SortField
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		field, desc := strings.CutPrefix(item, "-")
+		sort = append(sort, models.SortField{Field: field, Desc: desc})
+	}
+	return sort
+}
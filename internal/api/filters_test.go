@@ -0,0 +1,95 @@
+package api
+
+import (
+	"github.com/jaam8/online_song_library/internal/models"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []models.SongFilter
+	}{
+		{
+			name:  "equality with several values",
+			query: "group=Muse,Queen",
+			want:  []models.SongFilter{{Field: "group", Op: models.FilterEq, Values: []interface{}{"Muse", "Queen"}}},
+		},
+		{
+			name:  "escaped comma",
+			query: `song=Hello\,+Goodbye`,
+			want:  []models.SongFilter{{Field: "song", Op: models.FilterEq, Values: []interface{}{"Hello, Goodbye"}}},
+		},
+		{
+			name:  "like and negation",
+			query: "song_like!=live",
+			want:  []models.SongFilter{{Field: "song", Op: models.FilterLike, Values: []interface{}{"live"}, Negate: true}},
+		},
+		{
+			name:  "date range sorted by parameter name",
+			query: "release_date_to=31.12.2006&release_date_from=01.01.2006",
+			want: []models.SongFilter{
+				{Field: "release_date", Op: models.FilterGTE, Values: []interface{}{"01.01.2006"}},
+				{Field: "release_date", Op: models.FilterLTE, Values: []interface{}{"31.12.2006"}},
+			},
+		},
+		{
+			name:  "pagination parameters and empty values are skipped",
+			query: "page=2&per_page=10&sort=-id&cursor=abc&limit=5&total=true&group=",
+			want:  []models.SongFilter{},
+		},
+		{
+			name:  "unrelated parameters are ignored",
+			query: "utm_source=mail&_=1700000000&lang_like=en&group=Muse",
+			want:  []models.SongFilter{{Field: "group", Op: models.FilterEq, Values: []interface{}{"Muse"}}},
+		},
+		{
+			name:  "unsupported operator on a filter field is kept for validation",
+			query: "group_from=M",
+			want:  []models.SongFilter{{Field: "group", Op: models.FilterGTE, Values: []interface{}{"M"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatalf("bad query: %v", err)
+			}
+			if got := parseFilters(params); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFilters(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []string
+	}{
+		{raw: "a", want: []string{"a"}},
+		{raw: " a , b ", want: []string{"a", "b"}},
+		{raw: `a\,b,c`, want: []string{"a,b", "c"}},
+		{raw: `a\b`, want: []string{`a\b`}},
+		{raw: "a,,b", want: []string{"a", "", "b"}},
+	}
+	for _, tt := range tests {
+		if got := splitList(tt.raw); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitList(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	got := parseSort("-release_date, group,,")
+	want := []models.SortField{{Field: "release_date", Desc: true}, {Field: "group"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSort = %+v, want %+v", got, want)
+	}
+	if got := parseSort(""); got != nil {
+		t.Errorf("parseSort(\"\") = %+v, want nil", got)
+	}
+}
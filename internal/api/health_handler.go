@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"github.com/jaam8/online_song_library/internal/config"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	checkUp   = "up"
+	checkDown = "down"
+
+	healthOK       = "ok"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+)
+
+// healthCheck проверка одной зависимости; required-проверки влияют на готовность сервиса
+type healthCheck struct {
+	name     string
+	required bool
+	check    func(ctx context.Context) error
+}
+
+// HealthHandler отдает живость, готовность сервиса принимать трафик и состояние зависимостей
+type HealthHandler struct {
+	ready  atomic.Bool
+	cfg    config.HealthConfig
+	checks []healthCheck
+	l      *zap.Logger
+}
+
+func NewHealthHandler(cfg config.HealthConfig, log *zap.Logger) *HealthHandler {
+	return &HealthHandler{cfg: cfg, l: log}
+}
+
+// AddCheck регистрирует проверку зависимости; вызывается до запуска сервера
+func (h *HealthHandler) AddCheck(name string, required bool, check func(ctx context.Context) error) {
+	h.checks = append(h.checks, healthCheck{name: name, required: required, check: check})
+}
+
+// SetReady переключает готовность; перед остановкой сервис помечается неготовым,
+// чтобы балансировщик перестал направлять на него новые запросы
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+	h.l.Info("readiness changed", zap.Bool("ready", ready))
+}
+
+type statusResponse struct {
+	Status string        `json:"status" example:"ready"`
+	Checks []checkResult `json:"checks,omitempty"`
+}
+
+type checkResult struct {
+	Name      string  `json:"name" example:"postgres"`
+	Status    string  `json:"status" example:"up"`
+	Required  bool    `json:"required" example:"true"`
+	LatencyMs float64 `json:"latency_ms" example:"1.25"`
+	Error     string  `json:"error,omitempty" example:"connection refused"`
+}
+
+type healthResponse struct {
+	Status string        `json:"status" example:"ok"`
+	Ready  bool          `json:"ready" example:"true"`
+	Checks []checkResult `json:"checks"`
+}
+
+// runChecks выполняет проверки параллельно, каждую со своим дедлайном;
+// результаты идут в порядке регистрации проверок
+func (h *HealthHandler) runChecks(ctx context.Context, requiredOnly bool) []checkResult {
+	checks := make([]healthCheck, 0, len(h.checks))
+	for _, hc := range h.checks {
+		if !requiredOnly || hc.required {
+			checks = append(checks, hc)
+		}
+	}
+
+	results := make([]checkResult, len(checks))
+	var wg sync.WaitGroup
+	for i, hc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, h.cfg.CheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := hc.check(checkCtx)
+			results[i] = checkResult{
+				Name:      hc.name,
+				Status:    checkUp,
+				Required:  hc.required,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Status = checkDown
+				results[i].Error = err.Error()
+				h.l.Warn("health check failed",
+					zap.String("check", hc.name),
+					zap.Error(err))
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// @Summary Живость сервиса
+// @Description Возвращает 200, пока процесс запущен и обрабатывает запросы; зависимости не проверяются
+// @Tags health
+// @Produce json
+// @Success 200 {object} api.statusResponse "alive" example:{"status": "alive"}
+// @Router /livez [get]
+func (h *HealthHandler) LivezHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, statusResponse{Status: "alive"})
+}
+
+// @Summary Готовность сервиса
+// @Description Возвращает 200, если сервис запущен и обязательные зависимости (PostgreSQL, версия миграций,
+// @Description при HEALTH_CHECK_INFO — API /info) доступны, и 503 во время запуска, остановки или при сбое зависимости
+// @Tags health
+// @Produce json
+// @Success 200 {object} api.statusResponse "ready" example:{"status": "ready"}
+// @Failure 503 {object} api.statusResponse "not ready" example:{"status": "not ready"}
+// @Router /readyz [get]
+func (h *HealthHandler) ReadyzHandler(c echo.Context) error {
+	if !h.ready.Load() {
+		return c.JSON(http.StatusServiceUnavailable, statusResponse{Status: "not ready"})
+	}
+
+	var failed []checkResult
+	for _, res := range h.runChecks(c.Request().Context(), true) {
+		if res.Status == checkDown {
+			failed = append(failed, res)
+		}
+	}
+	if len(failed) > 0 {
+		return c.JSON(http.StatusServiceUnavailable, statusResponse{Status: "not ready", Checks: failed})
+	}
+	return c.JSON(http.StatusOK, statusResponse{Status: "ready"})
+}
+
+// @Summary Состояние зависимостей
+// @Description Возвращает статус и время ответа каждой зависимости.
+// @Description ok — все проверки прошли, degraded — упали только необязательные, down — упала обязательная (503)
+// @Tags health
+// @Produce json
+// @Success 200 {object} api.healthResponse "ok или degraded"
+// @Failure 503 {object} api.healthResponse "down"
+// @Router /health [get]
+func (h *HealthHandler) HealthHandler(c echo.Context) error {
+	resp := healthResponse{
+		Status: healthOK,
+		Ready:  h.ready.Load(),
+		Checks: h.runChecks(c.Request().Context(), false),
+	}
+	for _, res := range resp.Checks {
+		if res.Status != checkDown {
+			continue
+		}
+		if res.Required {
+			resp.Status = healthDown
+			break
+		}
+		resp.Status = healthDegraded
+	}
+
+	if resp.Status == healthDown {
+		return c.JSON(http.StatusServiceUnavailable, resp)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"errors"
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	lyricsFlat       = "flat"
+	lyricsStructured = "structured"
+	lyricsLRC        = "lrc"
+	lyricsJSON       = "json"
+
+	// maxLRCSize ограничение на размер загружаемого LRC
+	maxLRCSize = 256 << 10
+)
+
+// @Summary Текст песни
+// @Description Возвращает текст песни целиком: format=flat — одной строкой, format=structured — уникальные куплеты
+// @Description с типом (verse, chorus, pre_chorus, bridge, intro, outro) и порядок исполнения, в котором повторы
+// @Description припева ссылаются на один сохраненный куплет
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "song id"
+// @Param format query string false "flat или structured" default(structured)
+// @Success 200 {object} api.GetLyricsHandler.successResponse "received successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid format" example:{"error": "invalid format"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/lyrics [get]
+func (h *SongHandler) GetLyricsHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = lyricsStructured
+	}
+	if format != lyricsFlat && format != lyricsStructured {
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid format")
+	}
+	h.log(c).Debug("starting get lyrics",
+		zap.Uint("id", id),
+		zap.String("format", format))
+
+	text, structured, err := h.service.GetLyrics(c.Request().Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		h.log(c).Warn("song not found", zap.Uint("id", id))
+		return errorJSON(c, http.StatusNotFound, "song not found")
+	}
+	if err != nil {
+		h.log(c).Error("failed to get lyrics", zap.Uint("id", id), zap.Error(err))
+		return h.internalError(c, err)
+	}
+
+	type successResponse struct {
+		ID       uint             `json:"id" example:"1"`
+		Format   string           `json:"format" example:"structured"`
+		Text     *string          `json:"text,omitempty" example:"Ooh baby, don't you know I suffer?\n..."`
+		Sections []lyrics.Section `json:"sections,omitempty"`
+		Order    []string         `json:"order,omitempty" example:"verse-1,chorus-1,verse-2,chorus-1"`
+	}
+
+	response := successResponse{ID: id, Format: format}
+	if format == lyricsFlat {
+		response.Text = &text
+	} else {
+		response.Sections, response.Order = structured.Sections, structured.Order
+	}
+	h.log(c).Info("retrieved lyrics", zap.Uint("id", id))
+	return c.JSON(http.StatusOK, response)
+}
+
+// @Summary Загрузка синхронизированного текста
+// @Description Принимает текст в формате LRC ([mm:ss.xx]строка) телом запроса и сохраняет метки времени строк.
+// @Description Строки с одной меткой идут по возрастанию времени, строки с несколькими метками встают между ними.
+// @Description Нарушение порядка или повтор метки — ошибка.
+// @Description Тег [offset:ms] сдвигает все метки, остальные теги игнорируются.
+// @Description Каждая непустая строка должна совпадать со строкой текста песни. Повторная загрузка заменяет предыдущую
+// @Tags songs
+// @Accept plain
+// @Produce json
+// @Param id path int true "song id"
+// @Param lrc body string true "текст в формате LRC" example("[00:12.34]Ooh baby, don't you know I suffer?")
+// @Success 200 {object} api.PutSyncedLyricsHandler.successResponse "saved successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 413 {object} ErrorResponse "lrc is too large" example:{"error": "lrc is too large"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid lrc" example:{"error": "invalid lrc: line 2: timestamp 00:04.00 is not after 00:05.00"}
+// @Failure 422 {object} ErrorResponse "lrc does not match the song text" example:{"error": "synced lyrics do not match the song text"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/lyrics/synced [put]
+func (h *SongHandler) PutSyncedLyricsHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxLRCSize+1))
+	if err != nil {
+		h.log(c).Debug("failed to read lrc", zap.Error(err))
+		return errorJSON(c, http.StatusBadRequest, "failed to read body")
+	}
+	if len(body) > maxLRCSize {
+		return errorJSON(c, http.StatusRequestEntityTooLarge, "lrc is too large")
+	}
+	h.log(c).Debug("starting put synced lyrics",
+		zap.Uint("id", id),
+		zap.Int("size", len(body)))
+
+	synced, err := h.service.SetSyncedLyrics(c.Request().Context(), id, string(body))
+	if errors.Is(err, lyrics.ErrInvalidLRC) || errors.Is(err, service.ErrSyncedLyricsMismatch) {
+		return errorJSON(c, http.StatusUnprocessableEntity, err.Error())
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		h.log(c).Warn("song not found", zap.Uint("id", id))
+		return errorJSON(c, http.StatusNotFound, "song not found")
+	}
+	if err != nil {
+		h.log(c).Error("failed to save synced lyrics", zap.Uint("id", id), zap.Error(err))
+		return h.internalError(c, err)
+	}
+
+	type successResponse struct {
+		ID    uint `json:"id" example:"1"`
+		Lines int  `json:"lines" example:"42"`
+	}
+	return c.JSON(http.StatusOK, successResponse{ID: id, Lines: len(synced.Lines)})
+}
+
+// getSyncedLyrics загружает синхронизированный текст; при ошибке ответ уже записан и synced равен nil
+func (h *SongHandler) getSyncedLyrics(c echo.Context, id uint) (*lyrics.Synced, string, error) {
+	synced, text, err := h.service.GetSyncedLyrics(c.Request().Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		h.log(c).Warn("song not found", zap.Uint("id", id))
+		return nil, "", errorJSON(c, http.StatusNotFound, "song not found")
+	}
+	if errors.Is(err, service.ErrSyncedLyricsNotFound) {
+		return nil, "", errorJSON(c, http.StatusNotFound, "synced lyrics not found")
+	}
+	if err != nil {
+		h.log(c).Error("failed to get synced lyrics", zap.Uint("id", id), zap.Error(err))
+		return nil, "", h.internalError(c, err)
+	}
+	return synced, text, nil
+}
+
+// @Summary Синхронизированный текст
+// @Description Возвращает строки с метками времени: format=json — с концом строки и номером строки в тексте песни,
+// @Description format=lrc — файлом LRC
+// @Tags songs
+// @Produce json,plain
+// @Param id path int true "song id"
+// @Param format query string false "json или lrc" default(json)
+// @Success 200 {object} api.GetSyncedLyricsHandler.successResponse "received successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 404 {object} ErrorResponse "synced lyrics not found" example:{"error": "synced lyrics not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid format" example:{"error": "invalid format"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/lyrics/synced [get]
+func (h *SongHandler) GetSyncedLyricsHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = lyricsJSON
+	}
+	if format != lyricsJSON && format != lyricsLRC {
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid format")
+	}
+
+	synced, text, err := h.getSyncedLyrics(c, id)
+	if synced == nil {
+		return err
+	}
+	if format == lyricsLRC {
+		return c.String(http.StatusOK, synced.LRC())
+	}
+
+	type successResponse struct {
+		ID    uint                `json:"id" example:"1"`
+		Lines []lyrics.SyncedLine `json:"lines"`
+	}
+	return c.JSON(http.StatusOK, successResponse{ID: id, Lines: synced.Timed(text)})
+}
+
+// @Summary Строка в момент времени
+// @Description Возвращает строку, которая звучит в момент t, и следующую за ней. До первой строки current пустой,
+// @Description после последней — next. t задается в секундах (75.5) или длительностью Go (1m15.5s)
+// @Tags songs
+// @Produce json
+// @Param id path int true "song id"
+// @Param t query string true "время от начала трека" example(75.5)
+// @Success 200 {object} api.GetSyncedLineHandler.successResponse "received successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 404 {object} ErrorResponse "synced lyrics not found" example:{"error": "synced lyrics not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid t" example:{"error": "invalid t"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/lyrics/synced/at [get]
+func (h *SongHandler) GetSyncedLineHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	t, err := parseTrackTime(c.QueryParam("t"))
+	if err != nil {
+		h.log(c).Debug("failed to parse t", zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid t")
+	}
+
+	synced, text, err := h.getSyncedLyrics(c, id)
+	if synced == nil {
+		return err
+	}
+
+	type successResponse struct {
+		TimeMs  int64              `json:"t_ms" example:"75500"`
+		Current *lyrics.SyncedLine `json:"current"`
+		Next    *lyrics.SyncedLine `json:"next"`
+	}
+	lines := synced.Timed(text)
+	response := successResponse{TimeMs: t.Milliseconds()}
+	i := synced.At(t)
+	if i >= 0 {
+		response.Current = &lines[i]
+	}
+	if i+1 < len(lines) {
+		response.Next = &lines[i+1]
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// parseTrackTime разбирает время от начала трека: секунды (75.5) или длительность Go (1m15.5s)
+func parseTrackTime(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, errors.New("t is required")
+	}
+	var t time.Duration
+	if sec, err := strconv.ParseFloat(raw, 64); err == nil {
+		t = time.Duration(sec * float64(time.Second))
+	} else if t, err = time.ParseDuration(raw); err != nil {
+		return 0, err
+	}
+	if t < 0 {
+		return 0, errors.New("t is negative")
+	}
+	return t, nil
+}
@@ -1,30 +1,202 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/metrics"
+	"github.com/jaam8/online_song_library/internal/tracing"
+	"github.com/jaam8/online_song_library/pkg/logger"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// LoggingMiddleware добавляет логирование для каждого запроса
+const requestIDKey = "request_id"
+
+// RequestIDMiddleware берет идентификатор запроса из заголовка X-Request-ID или генерирует новый,
+// возвращает его в ответе и кладет в контекст логгер запроса с этим идентификатором
+func RequestIDMiddleware(log *zap.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+			c.Set(requestIDKey, id)
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+			ctx := logger.WithContext(req.Context(), log.With(zap.String("request_id", id)))
+			c.SetRequest(req.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// requestID возвращает идентификатор текущего запроса
+func requestID(c echo.Context) string {
+	id, _ := c.Get(requestIDKey).(string)
+	return id
+}
+
+// validRequestID проверяет идентификатор, пришедший от клиента, чтобы не писать в логи произвольные строки
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			r == '-' || r == '_' || r == '.' || r == ':') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// LoggingMiddleware пишет одну строку access-лога на запрос и открывает серверный спан;
+// родительский трейс берется из заголовка traceparent
 func LoggingMiddleware(log *zap.Logger) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			start := time.Now()
 			req := c.Request()
 
-			log.Info("Request",
-				zap.String("method", req.Method),
-				zap.String("path", req.URL.Path),
-				zap.String("query", req.URL.RawQuery),
-			)
+			ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+			route := c.Path()
+			if route == "" {
+				route = req.URL.Path
+			}
+			ctx, span := tracing.Tracer().Start(ctx, req.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(req.Method),
+					semconv.HTTPRoute(route),
+					semconv.URLPath(req.URL.Path),
+				))
+			defer span.End()
+
+			l := logger.FromContextOr(ctx, log)
+			if traceID := tracing.TraceID(ctx); traceID != "" {
+				l = l.With(zap.String("trace_id", traceID))
+				ctx = logger.WithContext(ctx, l)
+			}
+			c.SetRequest(req.WithContext(ctx))
+
 			err := next(c)
+			if err != nil {
+				// ответ на ошибку пишется сразу, чтобы в логе были итоговые статус и размер
+				c.Error(err)
+				span.RecordError(err)
+			}
+
+			status := c.Response().Status
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
 
-			log.Info("Response",
+			l.Info("Request",
 				zap.String("method", req.Method),
-				zap.Int("status", c.Response().Status),
 				zap.String("path", req.URL.Path),
+				zap.String("route", c.Path()),
+				zap.String("query", req.URL.RawQuery),
+				zap.Int("status", status),
+				zap.Duration("latency", time.Since(start)),
+				zap.Int64("bytes_in", req.ContentLength),
+				zap.Int64("bytes_out", c.Response().Size),
+				zap.String("remote_ip", c.RealIP()),
+				zap.String("user_agent", req.UserAgent()),
 			)
 
 			return err
 		}
 	}
 }
+
+// HTTPErrorHandler отдает ошибки echo (неизвестный маршрут, неподдерживаемый метод и т.п.)
+// в формате ErrorResponse с идентификатором запроса
+func HTTPErrorHandler(log *zap.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+		code, msg := http.StatusInternalServerError, "internal server error"
+		var he *echo.HTTPError
+		if errors.As(err, &he) {
+			code = he.Code
+			msg = strings.ToLower(http.StatusText(code))
+			if m, ok := he.Message.(string); ok {
+				msg = strings.ToLower(m)
+			}
+		} else {
+			logger.FromContextOr(c.Request().Context(), log).Error("unhandled error", zap.Error(err))
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(code)
+		} else {
+			err = errorJSON(c, code, msg)
+		}
+		if err != nil {
+			log.Error("failed to write error response", zap.Error(err))
+		}
+	}
+}
+
+// TimeoutMiddleware задает дедлайн контексту запроса; по его истечении отменяются запросы к БД и внешнему API
+func TimeoutMiddleware(timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if timeout <= 0 {
+				return next(c)
+			}
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// MetricsMiddleware считает запросы и их длительность по шаблону маршрута и статусу ответа
+func MetricsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				status = http.StatusInternalServerError
+				var he *echo.HTTPError
+				if errors.As(err, &he) {
+					status = he.Code
+				}
+			}
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			labels := []string{c.Request().Method, route, strconv.Itoa(status)}
+			metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+			metrics.HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/repository"
+	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// @Summary Обновление песни из внешнего API
+// @Description Повторно запрашивает информацию о песне по ее group и song и показывает изменения полей.
+// @Description Поля, заданные вручную, не обновляются. С dry_run=true изменения только возвращаются
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "song id"
+// @Param dry_run query bool false "не сохранять изменения" default(false)
+// @Param X-Author header string false "автор изменения для истории ревизий" default(enrichment)
+// @Param X-Change-Reason header string false "причина изменения" default(refreshed from upstream)
+// @Success 200 {object} api.RefreshSongHandler.successResponse "refreshed successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 409 {object} ErrorResponse "song was modified during refresh" example:{"error": "song was modified during refresh"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid dry_run" example:{"error": "invalid dry_run"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Failure 502 {object} ErrorResponse "music info service error" example:{"error": "music info service error"}
+// @Failure 503 {object} ErrorResponse "music info service unavailable" example:{"error": "music info service unavailable"}
+// @Router /{id}/refresh [post]
+func (h *SongHandler) RefreshSongHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+
+	dryRun := false
+	if dryRunStr := c.QueryParam("dry_run"); dryRunStr != "" {
+		d, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			h.log(c).Debug("failed to parse dry_run", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid dry_run")
+		}
+		dryRun = d
+	}
+	h.log(c).Debug("starting refresh song",
+		zap.Uint("id", id),
+		zap.Bool("dry_run", dryRun))
+
+	changes, err := h.service.RefreshSong(revisionContext(c), id, dryRun)
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, service.ErrSongInfoNotFound) {
+		h.log(c).Warn("song not found", zap.Uint("id", id), zap.Error(err))
+		return errorJSON(c, http.StatusNotFound, "song not found")
+	}
+	if errors.Is(err, repository.ErrSongModified) {
+		h.log(c).Warn("song modified during refresh", zap.Uint("id", id))
+		return errorJSON(c, http.StatusConflict, "song was modified during refresh")
+	}
+	if ok, resp := h.upstreamError(c, err); ok {
+		return resp
+	}
+	if err != nil {
+		h.log(c).Error("failed to refresh song", zap.Uint("id", id), zap.Error(err))
+		return h.internalError(c, err)
+	}
+
+	type successResponse struct {
+		ID      uint                 `json:"id" example:"1"`
+		Applied bool                 `json:"applied" example:"true"`
+		Changes []models.FieldChange `json:"changes"`
+	}
+	h.log(c).Info("song refreshed",
+		zap.Uint("id", id),
+		zap.Int("changes", len(changes)),
+		zap.Bool("dry_run", dryRun))
+	return c.JSON(http.StatusOK, successResponse{ID: id, Applied: !dryRun, Changes: changes})
+}
+
+// @Summary Фоновое обновление устаревших песен
+// @Description Ставит в фоновую очередь обновление всех песен, обогащенных раньше чем older_than_days дней назад
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param older_than_days query int false "возраст последнего обогащения в днях" default(30)
+// @Success 202 {object} api.RefreshStaleSongsHandler.acceptedResponse "scheduled for refresh" example:{"queued": 10}
+// @Failure 422 {object} ErrorResponse "invalid older_than_days" example:{"error": "invalid older_than_days"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /refresh [post]
+func (h *SongHandler) RefreshStaleSongsHandler(c echo.Context) error {
+	olderThanDays := 30
+	if daysStr := c.QueryParam("older_than_days"); daysStr != "" {
+		d, err := strconv.Atoi(daysStr)
+		if err != nil || d < 0 {
+			h.log(c).Debug("failed to parse older_than_days", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid older_than_days")
+		}
+		olderThanDays = d
+	}
+	h.log(c).Debug("starting refresh stale songs", zap.Int("older_than_days", olderThanDays))
+
+	queued, err := h.service.RefreshStaleSongs(c.Request().Context(), time.Duration(olderThanDays)*24*time.Hour)
+	if err != nil {
+		h.log(c).Error("failed to schedule refresh", zap.Error(err))
+		return h.internalError(c, err)
+	}
+
+	type acceptedResponse struct {
+		Queued int `json:"queued" example:"10"`
+	}
+	h.log(c).Info("stale songs scheduled for refresh", zap.Int("queued", queued))
+	return c.JSON(http.StatusAccepted, acceptedResponse{queued})
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	headerAuthor       = "X-Author"
+	headerChangeReason = "X-Change-Reason"
+)
+
+// revisionContext передает в сервис автора и причину изменения из заголовков X-Author и X-Change-Reason
+func revisionContext(c echo.Context) context.Context {
+	return service.WithRevision(c.Request().Context(),
+		strings.TrimSpace(c.Request().Header.Get(headerAuthor)),
+		strings.TrimSpace(c.Request().Header.Get(headerChangeReason)))
+}
+
+// parseRevisionID разбирает номер ревизии из пути или query-параметра
+func parseRevisionID(raw string) (uint, error) {
+	id, err := strconv.ParseUint(raw, 10, 0)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, errors.New("revision id must be positive")
+	}
+	return uint(id), nil
+}
+
+// revisionError пишет ответ на ошибку работы с ревизиями
+func (h *SongHandler) revisionError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		h.log(c).Warn("song not found", zap.Error(err))
+		return errorJSON(c, http.StatusNotFound, "song not found")
+	case errors.Is(err, service.ErrRevisionNotFound):
+		return errorJSON(c, http.StatusNotFound, "revision not found")
+	}
+	h.log(c).Error("failed to process revision", zap.Error(err))
+	return h.internalError(c, err)
+}
+
+// @Summary История изменений песни
+// @Description Возвращает ревизии песни от новых к старым. Ревизия — снимок полей песни после изменения
+// @Description с автором (заголовок X-Author) и причиной (заголовок X-Change-Reason)
+// @Tags revisions
+// @Produce json
+// @Param id path int true "song id"
+// @Param page query int false " " default(1)
+// @Param per_page query int false " " default(5)
+// @Success 200 {object} api.ListRevisionsHandler.successResponse "received successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid page" example:{"error": "invalid page"}
+// @Failure 422 {object} ErrorResponse "invalid per_page" example:{"error": "invalid per_page"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/revisions [get]
+func (h *SongHandler) ListRevisionsHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+
+	pagination := offsetPagination{Page: 1, PerPage: 5}
+	if pageStr := c.QueryParam("page"); pageStr != "" {
+		p, err := strconv.Atoi(pageStr)
+		if err != nil || p < 1 {
+			h.log(c).Debug("failed to parse page", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid page")
+		}
+		pagination.Page = p
+	}
+	if perPageStr := c.QueryParam("per_page"); perPageStr != "" {
+		pp, err := strconv.Atoi(perPageStr)
+		if err != nil || pp < 1 {
+			h.log(c).Debug("failed to parse per_page", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid per_page")
+		}
+		pagination.PerPage = pp
+	}
+
+	revisions, totalCount, err := h.service.ListRevisions(c.Request().Context(), id, pagination.PerPage, pagination.Page)
+	if err != nil {
+		return h.revisionError(c, err)
+	}
+	pagination.Total = totalCount
+
+	type successResponse struct {
+		Pagination offsetPagination      `json:"pagination"`
+		Revisions  []models.SongRevision `json:"revisions"`
+	}
+	return c.JSON(http.StatusOK, successResponse{Pagination: pagination, Revisions: revisions})
+}
+
+// @Summary Ревизия песни
+// @Description Возвращает снимок полей песни в ревизии rev
+// @Tags revisions
+// @Produce json
+// @Param id path int true "song id"
+// @Param rev path int true "revision id"
+// @Success 200 {object} models.SongRevision "received successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 404 {object} ErrorResponse "revision not found" example:{"error": "revision not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid rev" example:{"error": "invalid rev"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/revisions/{rev} [get]
+func (h *SongHandler) GetRevisionHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	revID, err := parseRevisionID(c.Param("rev"))
+	if err != nil {
+		h.log(c).Debug("failed to parse revision id", zap.String("rev", c.Param("rev")), zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid rev")
+	}
+
+	revision, err := h.service.GetRevision(c.Request().Context(), id, revID)
+	if err != nil {
+		return h.revisionError(c, err)
+	}
+	return c.JSON(http.StatusOK, revision)
+}
+
+// @Summary Сравнение ревизий песни
+// @Description Показывает изменения полей и построчный дифф текста между ревизиями from и to.
+// @Description Без to ревизия from сравнивается с последней ревизией.
+// @Description Строки diff: equal — без изменений, delete — есть только в from, insert — только в to;
+// @Description old_line и new_line — номера строк в тексте from и to
+// @Tags revisions
+// @Produce json
+// @Param id path int true "song id"
+// @Param from query int true "revision id"
+// @Param to query int false "revision id, по умолчанию последняя ревизия"
+// @Success 200 {object} models.RevisionDiff "received successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 404 {object} ErrorResponse "revision not found" example:{"error": "revision not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid from" example:{"error": "invalid from"}
+// @Failure 422 {object} ErrorResponse "invalid to" example:{"error": "invalid to"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/revisions/diff [get]
+func (h *SongHandler) DiffRevisionsHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	from, err := parseRevisionID(c.QueryParam("from"))
+	if err != nil {
+		h.log(c).Debug("failed to parse from", zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid from")
+	}
+	var to uint
+	if toStr := c.QueryParam("to"); toStr != "" {
+		if to, err = parseRevisionID(toStr); err != nil {
+			h.log(c).Debug("failed to parse to", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid to")
+		}
+	}
+
+	diff, err := h.service.DiffRevisions(c.Request().Context(), id, from, to)
+	if err != nil {
+		return h.revisionError(c, err)
+	}
+	return c.JSON(http.StatusOK, diff)
+}
+
+// @Summary Восстановление ревизии песни
+// @Description Возвращает песне значения полей из ревизии rev и сохраняет результат новой ревизией.
+// @Description Восстановленные поля считаются заданными вручную. Без X-Change-Reason причиной будет "restored revision N"
+// @Tags revisions
+// @Produce json
+// @Param id path int true "song id"
+// @Param rev path int true "revision id"
+// @Param X-Author header string false "автор изменения" default(anonymous)
+// @Param X-Change-Reason header string false "причина изменения"
+// @Success 200 {object} models.SongRevision "restored successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 404 {object} ErrorResponse "revision not found" example:{"error": "revision not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid rev" example:{"error": "invalid rev"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/revisions/{rev}/restore [post]
+func (h *SongHandler) RestoreRevisionHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	revID, err := parseRevisionID(c.Param("rev"))
+	if err != nil {
+		h.log(c).Debug("failed to parse revision id", zap.String("rev", c.Param("rev")), zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid rev")
+	}
+
+	revision, err := h.service.RestoreRevision(revisionContext(c), id, revID)
+	if err != nil {
+		return h.revisionError(c, err)
+	}
+	h.log(c).Info("revision restored",
+		zap.Uint("id", id),
+		zap.Uint("revision", revID))
+	return c.JSON(http.StatusOK, revision)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// @Summary Полнотекстовый поиск песен
+// @Description Ищет песни по группе, названию и тексту и сортирует их по релевантности; совпадения в названии весят больше.
+// @Description q в синтаксисе websearch_to_tsquery: слова, "фраза в кавычках", -исключение, or.
+// @Description highlights содержит строки текста с совпадениями, выделенными тегами <mark>.
+// @Description lang — конфигурация поиска (simple, english, russian), по умолчанию SEARCH_LANGUAGE
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param q query string true "поисковый запрос" example("\"black hole\" -muse")
+// @Param lang query string false "simple, english или russian"
+// @Param page query int false " " default(1)
+// @Param per_page query int false " " default(5)
+// @Success 200 {object} api.SearchSongsHandler.successResponse "found successfully"
+// @Failure 404 {object} ErrorResponse "songs not found" example:{"error": "songs not found"}
+// @Failure 422 {object} ErrorResponse "q is required" example:{"error": "q is required"}
+// @Failure 422 {object} ErrorResponse "unsupported lang" example:{"error": "unsupported lang"}
+// @Failure 422 {object} ErrorResponse "invalid page" example:{"error": "invalid page"}
+// @Failure 422 {object} ErrorResponse "invalid per_page" example:{"error": "invalid per_page"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /search [get]
+func (h *SongHandler) SearchSongsHandler(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return errorJSON(c, http.StatusUnprocessableEntity, "q is required")
+	}
+	lang := c.QueryParam("lang")
+
+	page := 1
+	perPage := 5
+
+	if pageStr := c.QueryParam("page"); pageStr != "" {
+		p, err := strconv.Atoi(pageStr)
+		if err != nil || p < 1 {
+			h.log(c).Debug("failed to parse page", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid page")
+		}
+		page = p
+	}
+
+	if perPageStr := c.QueryParam("per_page"); perPageStr != "" {
+		pp, err := strconv.Atoi(perPageStr)
+		if err != nil || pp < 1 {
+			h.log(c).Debug("failed to parse per_page", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid per_page")
+		}
+		perPage = pp
+	}
+
+	h.log(c).Debug("searching songs",
+		zap.String("q", query),
+		zap.String("lang", lang),
+		zap.Int("page", page),
+		zap.Int("per_page", perPage))
+
+	hits, totalCount, err := h.service.SearchSongs(c.Request().Context(), query, lang, perPage, page)
+	if errors.Is(err, service.ErrUnsupportedLanguage) {
+		return errorJSON(c, http.StatusUnprocessableEntity, "unsupported lang")
+	}
+	if err != nil {
+		h.log(c).Error("failed to search songs", zap.Error(err))
+		return h.internalError(c, err)
+	}
+	if len(hits) == 0 {
+		h.log(c).Warn("no songs found", zap.String("q", query))
+		return errorJSON(c, http.StatusNotFound, "songs not found")
+	}
+	h.log(c).Info("songs found", zap.Int("count", len(hits)))
+
+	type pagination struct {
+		Page    int   `json:"page" example:"1"`
+		PerPage int   `json:"per_page" example:"10"`
+		Total   int64 `json:"total" example:"100"`
+	}
+	type successResponse struct {
+		Pagination pagination             `json:"pagination"`
+		Songs      []models.SongSearchHit `json:"songs"`
+	}
+
+	return c.JSON(http.StatusOK, successResponse{
+		Songs: hits,
+		Pagination: pagination{
+			Page:    page,
+			PerPage: perPage,
+			Total:   totalCount,
+		},
+	})
+}
@@ -1,15 +1,19 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"github.com/jaam8/online_song_library/internal/lyrics"
 	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/repository"
 	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/jaam8/online_song_library/pkg/logger"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"math"
 	"net/http"
 	"strconv"
-	"strings"
 )
 
 type SongHandler struct {
@@ -17,185 +21,376 @@ type SongHandler struct {
 	l       *zap.Logger
 }
 type ErrorResponse struct {
-	Error string `json:"error" swaggertype:"string" example:"error text"`
+	Error     string `json:"error" swaggertype:"string" example:"error text"`
+	RequestID string `json:"request_id,omitempty" example:"3f2a9c1e7b4d4e8a9c0b1d2e3f4a5b6c"`
+}
+
+// errorJSON отвечает ошибкой с идентификатором запроса
+func errorJSON(c echo.Context, code int, msg string) error {
+	return c.JSON(code, ErrorResponse{Error: msg, RequestID: requestID(c)})
 }
 
 func New(service *service.SongService, log *zap.Logger) *SongHandler {
 	return &SongHandler{service: service, l: log}
 }
 
+// log возвращает логгер запроса, а если его нет — логгер обработчика
+func (h *SongHandler) log(c echo.Context) *zap.Logger {
+	return logger.FromContextOr(c.Request().Context(), h.l)
+}
+
+// internalError отвечает 504, если истек дедлайн запроса, и 500 в остальных случаях
+func (h *SongHandler) internalError(c echo.Context, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errorJSON(c, http.StatusGatewayTimeout, "request timeout")
+	}
+	return errorJSON(c, http.StatusInternalServerError, "internal server error")
+}
+
+// upstreamError отвечает клиенту на ошибку обращения к внешнему API.
+// Возвращает false, если err не относится к внешнему API
+func (h *SongHandler) upstreamError(c echo.Context, err error) (bool, error) {
+	var circuitErr *service.CircuitOpenError
+	switch {
+	case errors.As(err, &circuitErr):
+		h.log(c).Warn("music info service unavailable", zap.Error(err))
+		retryAfter := max(1, int(math.Ceil(circuitErr.RetryAfter.Seconds())))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		return true, errorJSON(c, http.StatusServiceUnavailable, "music info service unavailable")
+	case errors.Is(err, service.ErrUpstreamFailed):
+		h.log(c).Error("music info service error", zap.Error(err))
+		return true, errorJSON(c, http.StatusBadGateway, "music info service error")
+	case errors.Is(err, service.ErrBadUpstreamPayload):
+		h.log(c).Error("bad music info payload", zap.Error(err))
+		return true, errorJSON(c, http.StatusBadGateway, err.Error())
+	}
+	return false, nil
+}
+
+// parseID извлекает ID песни из пути запроса
+func parseID(c echo.Context) (uint, error) {
+	id64, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(id64), nil
+}
+
 // @Summary Добавление новой песни
-// @Description Добавляет новую песню, получая информацию о ней через запрос к стороннему API, возвращает ID песни
+// @Description Добавляет новую песню, получая информацию о ней через запрос к стороннему API, возвращает ID песни.
+// @Description Если release_date, text и link переданы в запросе, сторонний API не вызывается;
+// @Description переданные поля сохраняются с источником manual.
+// @Description С async=true песня сохраняется в состоянии pending и обогащается в фоне
 // @Tags songs
 // @Accept json
 // @Produce json
-// @Param song body api.CreateSongHandler.request true "название группы и песни"
+// @Param song body models.SongRaw true "название группы и песни, опционально release_date (DD.MM.YYYY), text и link"
+// @Param async query bool false "обогатить песню в фоне" default(false)
+// @Param X-Author header string false "автор изменения для истории ревизий" default(anonymous)
+// @Param X-Change-Reason header string false "причина изменения" default(created)
 // @Success 201 {object} api.CreateSongHandler.successResponse "successfully created" example:{"id": 1}
+// @Success 202 {object} api.CreateSongHandler.acceptedResponse "accepted for enrichment" example:{"id": 1, "enrichment_status": "pending"}
 // @Failure 400 {object} ErrorResponse "invalid request" example:{"error": "invalid request"}
 // @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
 // @Failure 422 {object} ErrorResponse "all field are required" example:{"error": "all field are required"}
+// @Failure 422 {object} ErrorResponse "invalid async" example:{"error": "invalid async"}
+// @Failure 422 {object} ErrorResponse "invalid release_date" example:{"error": "invalid release_date"}
 // @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Failure 502 {object} ErrorResponse "music info service error" example:{"error": "music info service error"}
+// @Failure 502 {object} ErrorResponse "bad upstream payload" example:{"error": "bad upstream payload: missing required fields releaseDate"}
+// @Failure 503 {object} ErrorResponse "music info service unavailable" example:{"error": "music info service unavailable"}
+// @Header 503 {integer} Retry-After "seconds until the music info service is retried"
 // @Router / [post]
 func (h *SongHandler) CreateSongHandler(c echo.Context) error {
-	h.l.Debug("starting create song")
-	type request struct {
-		Group string `json:"group" example:"Muse" swaggertype:"string"`
-		Song  string `json:"song" example:"Supermassive Black Hole" swaggertype:"string"`
-	}
+	h.log(c).Debug("starting create song")
 	type successResponse struct {
 		ID uint `json:"id" example:"1" swaggertype:"integer"`
 	}
-	var req request
+	type acceptedResponse struct {
+		ID               uint                    `json:"id" example:"1" swaggertype:"integer"`
+		EnrichmentStatus models.EnrichmentStatus `json:"enrichment_status" example:"pending" swaggertype:"string"`
+	}
+	var req models.SongRaw
 	if err := c.Bind(&req); err != nil {
-		h.l.Debug("failed to bind request body", zap.Error(err))
-		return c.JSON(http.StatusBadRequest, ErrorResponse{"invalid request"})
+		h.log(c).Debug("failed to bind request body", zap.Error(err))
+		return errorJSON(c, http.StatusBadRequest, "invalid request")
 	}
-	h.l.Debug("parsed request body",
+	h.log(c).Debug("parsed request body",
 		zap.String("group", req.Group),
-		zap.String("song", req.Song))
+		zap.String("song", req.Song),
+		zap.Bool("has_details", req.HasDetails()))
 
 	if req.Group == "" || req.Song == "" {
-		h.l.Debug("validation failed: missing required fields")
-		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"all field are required"})
+		h.log(c).Debug("validation failed: missing required fields")
+		return errorJSON(c, http.StatusUnprocessableEntity, "all field are required")
 	}
 
-	id, err := h.service.CreateSong(req.Group, req.Song)
-	if err != nil {
-		h.l.Debug("error creating song", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{"internal server error"})
+	async := false
+	if asyncStr := c.QueryParam("async"); asyncStr != "" {
+		a, err := strconv.ParseBool(asyncStr)
+		if err != nil {
+			h.log(c).Debug("failed to parse async", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid async")
+		}
+		async = a
+	}
+
+	if async && !req.HasDetails() {
+		id, err := h.service.CreateSongAsync(revisionContext(c), req)
+		if errors.Is(err, service.ErrParsingTime) {
+			h.log(c).Debug("failed to parse release_date", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid release_date")
+		}
+		if err != nil {
+			h.log(c).Error("error creating song", zap.Error(err))
+			return h.internalError(c, err)
+		}
+		h.log(c).Info("song accepted for enrichment", zap.Uint("id", id))
+		return c.JSON(http.StatusAccepted, acceptedResponse{id, models.EnrichmentPending})
+	}
+
+	id, err := h.service.CreateSong(revisionContext(c), req)
+	if errors.Is(err, service.ErrParsingTime) {
+		h.log(c).Debug("failed to parse release_date", zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid release_date")
+	}
+	if errors.Is(err, service.ErrSongInfoNotFound) {
+		h.log(c).Warn("song not found")
+		return errorJSON(c, http.StatusNotFound, "song not found")
 	}
-	if id == 0 {
-		h.l.Warn("song not found")
-		return c.JSON(http.StatusNotFound, ErrorResponse{"song not found"})
+	if ok, resp := h.upstreamError(c, err); ok {
+		return resp
+	}
+	if err != nil {
+		h.log(c).Debug("error creating song", zap.Error(err))
+		return h.internalError(c, err)
 	}
-	h.l.Info("song created successfully", zap.Uint("id", id))
+	h.log(c).Info("song created successfully", zap.Uint("id", id))
 	return c.JSON(http.StatusCreated, successResponse{id})
 }
 
 // @Summary Получение всех песен с фильтрацией и пагинацией
-// @Description Получение всех песен с фильтрацией и пагинацией
+// @Description Получение всех песен с фильтрацией и пагинацией.
+// @Description Несколько значений через запятую объединяются через OR (group=Muse,Queen), запятую в значении можно экранировать как \,.
+// @Description Суффикс _like — вхождение без учета регистра, _from и _to — границы даты включительно.
+// @Description ! в конце имени параметра инвертирует условие: group!=Muse, song_like!=live.
+// @Description sort=field[,-field] сортирует по id, group, song и release_date; при равенстве песни упорядочиваются по id.
+// @Description Пагинация курсором включается параметрами limit или cursor: ответ содержит cursor.next и cursor.prev,
+// @Description которые передаются в cursor без изменений вместе с теми же фильтрами. Курсор помнит сортировку, sort можно не повторять.
+// @Description Общее число песен в этом режиме считается только при total=true. page и per_page с курсором не сочетаются
 // @Tags songs
 // @Accept json
 // @Produce json
-// @Param group query string false " "
-// @Param song query string false " "
-// @Param release_date query string false " "
-// @Param text query string false " "
-// @Param link query string false " "
+// @Param group query string false "точное совпадение, через запятую — любое из значений"
+// @Param group_like query string false "вхождение в название группы без учета регистра"
+// @Param song query string false "точное совпадение, через запятую — любое из значений"
+// @Param song_like query string false "вхождение в название песни без учета регистра"
+// @Param release_date query string false "дата выхода DD.MM.YYYY"
+// @Param release_date_from query string false "дата выхода не раньше DD.MM.YYYY"
+// @Param release_date_to query string false "дата выхода не позже DD.MM.YYYY"
+// @Param text query string false "точное совпадение текста"
+// @Param text_like query string false "вхождение в текст без учета регистра"
+// @Param link query string false "точное совпадение ссылки"
+// @Param link_like query string false "вхождение в ссылку без учета регистра"
+// @Param sort query string false "поля сортировки через запятую: id, group, song, release_date; минус — по убыванию" example(-release_date,group)
 // @Param page query int false " " default(1)
 // @Param per_page query int false " " default(5)
+// @Param cursor query string false "курсор из cursor.next или cursor.prev предыдущего ответа"
+// @Param limit query int false "размер страницы в режиме курсора" default(5)
+// @Param total query bool false "посчитать общее число песен в режиме курсора" default(false)
 // @Success 200 {object} api.GetAllSongsHandler.successResponse "received successfully"
 // @Failure 404 {object} ErrorResponse "songs not found" example:{"error": "songs not found"}
 // @Failure 422 {object} ErrorResponse "invalid page" example:{"error": "invalid page"}
 // @Failure 422 {object} ErrorResponse "invalid per_page" example:{"error": "invalid per_page"}
+// @Failure 422 {object} ErrorResponse "invalid limit" example:{"error": "invalid limit"}
+// @Failure 422 {object} ErrorResponse "invalid total" example:{"error": "invalid total"}
+// @Failure 422 {object} ErrorResponse "cursor and page are mutually exclusive" example:{"error": "cursor and page are mutually exclusive"}
+// @Failure 422 {object} ErrorResponse "invalid cursor" example:{"error": "invalid cursor: bad encoding"}
 // @Failure 422 {object} ErrorResponse "invalid release_date" example:{"error": "invalid release_date"}
+// @Failure 422 {object} ErrorResponse "invalid filter" example:{"error": "invalid filter: group does not support gte"}
+// @Failure 422 {object} ErrorResponse "invalid sort" example:{"error": "invalid sort: unknown field text"}
 // @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
 // @Router / [get]
 func (h *SongHandler) GetAllSongsHandler(c echo.Context) error {
-	filters := make(map[string]interface{})
-	if group := c.QueryParam("group"); group != "" {
-		filters["group"] = group
-	}
-	if song := c.QueryParam("song"); song != "" {
-		filters["song"] = song
-	}
-	if releaseDate := c.QueryParam("release_date"); releaseDate != "" {
-		filters["release_date"] = releaseDate
-	}
-	if text := c.QueryParam("text"); text != "" {
-		filters["text"] = text
-	}
-	if link := c.QueryParam("link"); link != "" {
-		filters["link"] = link
+	filters := parseFilters(c.QueryParams())
+	sort := parseSort(c.QueryParam("sort"))
+
+	type successResponse struct {
+		Pagination *offsetPagination `json:"pagination,omitempty"`
+		Cursor     *cursorPagination `json:"cursor,omitempty"`
+		Songs      []models.Song     `json:"songs"`
 	}
 
-	page := 1
-	perPage := 5
+	var (
+		response successResponse
+		err      error
+	)
+	if c.QueryParam("cursor") != "" || c.QueryParam("limit") != "" {
+		response.Cursor, response.Songs, err = h.getSongsPage(c, filters, sort)
+	} else {
+		response.Pagination, response.Songs, err = h.getSongsByOffset(c, filters, sort)
+	}
+	if err != nil || c.Response().Committed {
+		return err
+	}
+	if len(response.Songs) == 0 {
+		h.log(c).Warn("no songs found")
+		return errorJSON(c, http.StatusNotFound, "songs not found")
+	}
+	h.log(c).Info("retrieved songs", zap.Int("count", len(response.Songs)))
+	return c.JSON(http.StatusOK, response)
+}
+
+type offsetPagination struct {
+	Page    int   `json:"page" example:"1"`
+	PerPage int   `json:"per_page" example:"10"`
+	Total   int64 `json:"total" example:"100"`
+}
+
+type cursorPagination struct {
+	Limit int    `json:"limit" example:"10"`
+	Next  string `json:"next,omitempty" example:"eyJzIjoiaWQiLCJ2IjpbIjEwIl19"`
+	Prev  string `json:"prev,omitempty" example:"eyJzIjoiaWQiLCJ2IjpbIjEiXSwiYiI6dHJ1ZX0"`
+	Total *int64 `json:"total,omitempty" example:"100"`
+}
+
+// getSongsByOffset читает страницу по page и per_page; при ошибке ответ уже записан
+func (h *SongHandler) getSongsByOffset(c echo.Context, filters []models.SongFilter, sort []models.SortField) (*offsetPagination, []models.Song, error) {
+	pagination := &offsetPagination{Page: 1, PerPage: 5}
 
 	if pageStr := c.QueryParam("page"); pageStr != "" {
 		p, err := strconv.Atoi(pageStr)
 		if err != nil || p < 1 {
-			h.l.Debug("failed to parse page", zap.Error(err))
-			return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"invalid page"})
+			h.log(c).Debug("failed to parse page", zap.Error(err))
+			return nil, nil, errorJSON(c, http.StatusUnprocessableEntity, "invalid page")
 		}
-		page = p
+		pagination.Page = p
 	}
 
 	if perPageStr := c.QueryParam("per_page"); perPageStr != "" {
 		pp, err := strconv.Atoi(perPageStr)
 		if err != nil || pp < 1 {
-			h.l.Debug("failed to parse per_page", zap.Error(err))
-			return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"invalid per_page"})
+			h.log(c).Debug("failed to parse per_page", zap.Error(err))
+			return nil, nil, errorJSON(c, http.StatusUnprocessableEntity, "invalid per_page")
 		}
-		perPage = pp
+		pagination.PerPage = pp
 	}
 
-	h.l.Debug("fetching songs",
+	h.log(c).Debug("fetching songs",
 		zap.Any("filters", filters),
-		zap.Int("page", page),
-		zap.Int("per_page", perPage))
+		zap.Any("sort", sort),
+		zap.Int("page", pagination.Page),
+		zap.Int("per_page", pagination.PerPage))
 
-	songs, totalCount, err := h.service.GetAllSong(perPage, page, filters)
-	if errors.Is(err, service.ErrParsingTime) {
-		h.l.Debug("failed to parse release_date", zap.Error(err))
-		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"invalid release_date"})
-	}
+	songs, totalCount, err := h.service.GetAllSong(c.Request().Context(), pagination.PerPage, pagination.Page, filters, sort)
 	if err != nil {
-		h.l.Error("failed to get all songs", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{"internal server error"})
+		return nil, nil, h.listError(c, err)
 	}
-	if len(songs) == 0 {
-		h.l.Warn("no songs found")
-		return c.JSON(http.StatusNotFound, ErrorResponse{"songs not found"})
+	pagination.Total = totalCount
+	return pagination, songs, nil
+}
+
+// getSongsPage читает страницу по cursor и limit и отдает курсоры соседних страниц;
+// при ошибке ответ уже записан
+func (h *SongHandler) getSongsPage(c echo.Context, filters []models.SongFilter, sort []models.SortField) (*cursorPagination, []models.Song, error) {
+	if c.QueryParam("page") != "" || c.QueryParam("per_page") != "" {
+		return nil, nil, errorJSON(c, http.StatusUnprocessableEntity, "cursor and page are mutually exclusive")
 	}
-	h.l.Info("retrieved songs", zap.Int("count", len(songs)))
+	pagination := &cursorPagination{Limit: 5}
 
-	type pagination struct {
-		Page    int   `json:"page" example:"1"`
-		PerPage int   `json:"per_page" example:"10"`
-		Total   int64 `json:"total" example:"100"`
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 {
+			h.log(c).Debug("failed to parse limit", zap.Error(err))
+			return nil, nil, errorJSON(c, http.StatusUnprocessableEntity, "invalid limit")
+		}
+		pagination.Limit = l
 	}
-	type successResponse struct {
-		Pagination pagination    `json:"pagination"`
-		Songs      []models.Song `json:"songs"`
+
+	withTotal := false
+	if totalStr := c.QueryParam("total"); totalStr != "" {
+		t, err := strconv.ParseBool(totalStr)
+		if err != nil {
+			h.log(c).Debug("failed to parse total", zap.Error(err))
+			return nil, nil, errorJSON(c, http.StatusUnprocessableEntity, "invalid total")
+		}
+		withTotal = t
 	}
 
-	response := successResponse{
-		Songs: songs,
-		Pagination: pagination{
-			Page:    page,
-			PerPage: perPage,
-			Total:   totalCount,
-		},
+	h.log(c).Debug("fetching songs page",
+		zap.Any("filters", filters),
+		zap.Any("sort", sort),
+		zap.Bool("cursor", c.QueryParam("cursor") != ""),
+		zap.Int("limit", pagination.Limit),
+		zap.Bool("total", withTotal))
+
+	page, err := h.service.GetSongsPage(c.Request().Context(), filters, sort, c.QueryParam("cursor"), pagination.Limit, withTotal)
+	if err != nil {
+		return nil, nil, h.listError(c, err)
 	}
-	return c.JSON(http.StatusOK, response)
+	pagination.Next, pagination.Prev, pagination.Total = page.Next, page.Prev, page.Total
+	return pagination, page.Songs, nil
+}
+
+// listError пишет ответ на ошибку чтения списка песен
+func (h *SongHandler) listError(c echo.Context, err error) error {
+	if errors.Is(err, service.ErrParsingTime) {
+		h.log(c).Debug("failed to parse release_date", zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid release_date")
+	}
+	if errors.Is(err, repository.ErrInvalidFilter) ||
+		errors.Is(err, repository.ErrInvalidSort) ||
+		errors.Is(err, repository.ErrInvalidCursor) {
+		h.log(c).Debug("invalid list query", zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, err.Error())
+	}
+	h.log(c).Error("failed to get all songs", zap.Error(err))
+	return h.internalError(c, err)
 }
 
 // @Summary Получение песни и пагинация текста
-// @Description Получение песни и пагинация текста по куплетам
+// @Description Получение песни и пагинация текста по куплетам.
+// @Description Куплеты разделяются пустыми строками; mode=line разбивает текст на отдельные строки.
+// @Description Для каждого элемента возвращаются номер куплета и номера первой и последней строки без учета пустых.
+// @Description Перевод выбирается параметром lang или заголовком Accept-Language; тогда verses содержит перевод,
+// @Description а original — куплеты оригинала с теми же номерами. Без подходящего перевода отдается оригинал
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param id query int true "song id"
+// @Param mode query string false "stanza или line" default(stanza)
+// @Param lang query string false "язык перевода, тег BCP 47"
+// @Param Accept-Language header string false "предпочитаемые языки перевода" example(ru, en;q=0.8)
 // @Param page query int false "page number" default(1)
 // @Param per_page query int false "items per page" default(5)
 // @Success 200 {object} api.GetSongHandler.successResponse "received successfully"
 // @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 404 {object} ErrorResponse "translation not found" example:{"error": "translation not found"}
 // @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid mode" example:{"error": "invalid mode"}
+// @Failure 422 {object} ErrorResponse "invalid lang" example:{"error": "invalid lang"}
 // @Failure 422 {object} ErrorResponse "invalid page" example:{"error": "invalid page"}
 // @Failure 422 {object} ErrorResponse "invalid per_page" example:{"error": "invalid per_page"}
 // @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
 // @Router /{id} [get]
 func (h *SongHandler) GetSongHandler(c echo.Context) error {
-	id64, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	id, err := parseID(c)
 	if err != nil {
-		h.l.Warn("failed to parse song id",
+		h.log(c).Warn("failed to parse song id",
 			zap.String("id", c.Param("id")),
 			zap.Error(err))
-		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"invalid id"})
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	h.log(c).Debug("starting get song", zap.Uint("id", id))
+
+	mode, err := lyrics.ParseMode(c.QueryParam("mode"))
+	if err != nil {
+		h.log(c).Debug("failed to parse mode", zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid mode")
 	}
-	id := uint(id64)
-	h.l.Debug("starting get song", zap.Uint("id", id))
 
 	page := 1
 	perPage := 5
@@ -203,8 +398,8 @@ func (h *SongHandler) GetSongHandler(c echo.Context) error {
 	if pageStr := c.QueryParam("page"); pageStr != "" {
 		p, err := strconv.Atoi(pageStr)
 		if err != nil || p < 1 {
-			h.l.Debug("failed to parse page", zap.Error(err))
-			return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"invalid page"})
+			h.log(c).Debug("failed to parse page", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid page")
 		}
 		page = p
 	}
@@ -212,46 +407,68 @@ func (h *SongHandler) GetSongHandler(c echo.Context) error {
 	if perPageStr := c.QueryParam("per_page"); perPageStr != "" {
 		pp, err := strconv.Atoi(perPageStr)
 		if err != nil || pp < 1 {
-			h.l.Debug("failed to parse per_page", zap.Error(err))
-			return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"invalid per_page"})
+			h.log(c).Debug("failed to parse per_page", zap.Error(err))
+			return errorJSON(c, http.StatusUnprocessableEntity, "invalid per_page")
 		}
 		perPage = pp
 	}
 
-	h.l.Debug("starting pagination for song text",
+	h.log(c).Debug("starting pagination for song text",
 		zap.Uint("id", id),
+		zap.String("mode", string(mode)),
 		zap.Int("page", page),
 		zap.Int("per_page", perPage))
 
-	song, err := h.service.GetSong(id)
+	song, err := h.service.GetSong(c.Request().Context(), id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		h.l.Warn("song not found", zap.Uint("id", id))
-		return c.JSON(http.StatusNotFound, ErrorResponse{"song not found"})
+		h.log(c).Warn("song not found", zap.Uint("id", id))
+		return errorJSON(c, http.StatusNotFound, "song not found")
 	}
 	if err != nil {
-		h.l.Error("failed to get song", zap.Uint("id", id), zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{"internal server error"})
+		h.log(c).Error("failed to get song", zap.Uint("id", id), zap.Error(err))
+		return h.internalError(c, err)
+	}
+
+	translation, err := h.songTranslation(c, id)
+	if err != nil || c.Response().Committed {
+		return err
 	}
 
 	type successResponse struct {
-		Verses []string `json:"verses" example:"[\"ooh baby, don't you know i suffer?\", \"ooh baby, can you hear me moan?\"]"`
-		Page   int      `json:"page" example:"1"`
-		Total  int      `json:"total" example:"10"`
+		Mode     lyrics.Mode     `json:"mode" example:"stanza"`
+		Language string          `json:"language,omitempty" example:"ru"`
+		Verses   []lyrics.Stanza `json:"verses"`
+		Original []lyrics.Stanza `json:"original,omitempty"`
+		Page     int             `json:"page" example:"1"`
+		Total    int             `json:"total" example:"10"`
 	}
 
-	verses := strings.Split(song.Text, "\n")
-	totalVerses := len(verses)
+	response := successResponse{Mode: mode, Verses: []lyrics.Stanza{}, Page: page}
+	text := song.Text
+	if translation != nil {
+		text = translation.Text
+		response.Language = translation.Language
+		c.Response().Header().Set("Content-Language", translation.Language)
+	}
+	verses := lyrics.Split(lyrics.Parse(text), mode)
+	response.Total = len(verses)
 	startIdx := (page - 1) * perPage
-	if startIdx >= totalVerses {
-		return c.JSON(http.StatusOK, successResponse{Verses: []string{}, Page: page, Total: totalVerses})
+	if startIdx >= response.Total {
+		return c.JSON(http.StatusOK, response)
 	}
 	endIdx := startIdx + perPage
-	if endIdx > totalVerses {
-		endIdx = totalVerses
+	if endIdx > response.Total {
+		endIdx = response.Total
+	}
+	response.Verses = verses[startIdx:endIdx]
+	if translation != nil {
+		response.Original = alignedStanzas(lyrics.Parse(song.Text), response.Verses)
 	}
 
-	h.l.Info("retrieved song text", zap.Uint("id", id))
-	return c.JSON(http.StatusOK, successResponse{Verses: verses[startIdx:endIdx], Page: page, Total: totalVerses})
+	h.log(c).Info("retrieved song text",
+		zap.Uint("id", id),
+		zap.String("language", response.Language))
+	return c.JSON(http.StatusOK, response)
 }
 
 // @Summary Обновление песни
@@ -261,53 +478,60 @@ func (h *SongHandler) GetSongHandler(c echo.Context) error {
 // @Produce json
 // @Param id query int true "song id"
 // @Param song body models.SongRaw true "song update data"
+// @Param X-Author header string false "автор изменения для истории ревизий" default(anonymous)
+// @Param X-Change-Reason header string false "причина изменения" default(updated)
 // @Success 200 {object} api.UpdateSongHandler.successResponse "updated successfully" example:{"success": true}
 // @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
 // @Failure 400 {object} ErrorResponse "invalid data" example:{"error": "invalid data"}
 // @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
 // @Failure 422 {object} ErrorResponse "all fields are required" example:{"error": "all fields are required"}
+// @Failure 422 {object} ErrorResponse "invalid release_date" example:{"error": "invalid release_date"}
 // @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
 // @Router /{id} [put]
 func (h *SongHandler) UpdateSongHandler(c echo.Context) error {
-	id64, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	id, err := parseID(c)
 	if err != nil {
-		h.l.Warn("failed to parse song id",
+		h.log(c).Warn("failed to parse song id",
 			zap.String("id", c.Param("id")),
 			zap.Error(err))
-		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"invalid id"})
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
 	}
-	id := uint(id64)
-	h.l.Debug("starting update song", zap.Uint("id", id))
+	h.log(c).Debug("starting update song", zap.Uint("id", id))
 
 	var updatedSong models.SongRaw
 	if err = c.Bind(&updatedSong); err != nil {
-		h.l.Debug("failed to bind request body", zap.Error(err))
-		return c.JSON(http.StatusBadRequest, ErrorResponse{"invalid data"})
+		h.log(c).Debug("failed to bind request body", zap.Error(err))
+		return errorJSON(c, http.StatusBadRequest, "invalid data")
 	}
 
 	if updatedSong.Song == "" || updatedSong.Group == "" || updatedSong.ReleaseDate == "" ||
 		updatedSong.Text == "" || updatedSong.Link == "" {
-		h.l.Debug("validation failed: one or more fields are empty",
+		h.log(c).Debug("validation failed: one or more fields are empty",
 			zap.Any("data", updatedSong))
-		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"all fields are required"})
+		return errorJSON(c, http.StatusUnprocessableEntity, "all fields are required")
 	}
 
-	err = h.service.UpdateSong(id, updatedSong)
+	err = h.service.UpdateSong(revisionContext(c), id, updatedSong)
+	if errors.Is(err, service.ErrParsingTime) {
+		h.log(c).Debug("failed to parse release_date", zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid release_date")
+	}
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		h.l.Warn("song not found", zap.Uint("id", id))
-		return c.JSON(http.StatusNotFound, ErrorResponse{"song not found"})
+		h.log(c).Warn("song not found", zap.Uint("id", id))
+		return errorJSON(c, http.StatusNotFound, "song not found")
 	}
 	if err != nil {
-		h.l.Error("failed to update song",
+		h.log(c).Error("failed to update song",
 			zap.Uint("id", id),
 			zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{"internal server error"})
+		return h.internalError(c, err)
 	}
 
 	type successResponse struct {
 		Success bool `json:"success" example:"true"`
 	}
-	h.l.Info("song updated successfully", zap.Uint("id", id))
+	h.log(c).Info("song updated successfully", zap.Uint("id", id))
 	return c.JSON(http.StatusOK, successResponse{true})
 }
 
@@ -321,29 +545,78 @@ func (h *SongHandler) UpdateSongHandler(c echo.Context) error {
 // @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
 // @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
 // @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
 // @Router /{id} [delete]
 func (h *SongHandler) DeleteSongHandler(c echo.Context) error {
-	id64, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	id, err := parseID(c)
 	if err != nil {
-		h.l.Warn("failed to parse song id",
+		h.log(c).Warn("failed to parse song id",
 			zap.String("id", c.Param("id")),
 			zap.Error(err))
-		return c.JSON(http.StatusUnprocessableEntity, ErrorResponse{"invalid id"})
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
 	}
-	id := uint(id64)
-	h.l.Debug("starting delete song", zap.Uint("id", id))
-	err = h.service.DeleteSong(id)
+	h.log(c).Debug("starting delete song", zap.Uint("id", id))
+	err = h.service.DeleteSong(c.Request().Context(), id)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		h.l.Warn("song not found", zap.Uint("id", id))
-		return c.JSON(http.StatusNotFound, ErrorResponse{"song not found"})
+		h.log(c).Warn("song not found", zap.Uint("id", id))
+		return errorJSON(c, http.StatusNotFound, "song not found")
 	}
 	if err != nil {
-		h.l.Error("failed to delete song", zap.Uint("id", id), zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{"internal server error"})
+		h.log(c).Error("failed to delete song", zap.Uint("id", id), zap.Error(err))
+		return h.internalError(c, err)
 	}
 	type successResponse struct {
 		Success bool `json:"success" example:"true"`
 	}
-	h.l.Info("song deleted successfully", zap.Uint("id", id))
+	h.log(c).Info("song deleted successfully", zap.Uint("id", id))
 	return c.JSON(http.StatusOK, successResponse{true})
 }
+
+// @Summary Статус обогащения песни
+// @Description Возвращает состояние фонового обогащения песни данными из стороннего API
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "song id"
+// @Success 200 {object} api.GetEnrichmentStatusHandler.successResponse "received successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/enrichment [get]
+func (h *SongHandler) GetEnrichmentStatusHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	h.log(c).Debug("starting get enrichment status", zap.Uint("id", id))
+
+	song, err := h.service.GetSong(c.Request().Context(), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		h.log(c).Warn("song not found", zap.Uint("id", id))
+		return errorJSON(c, http.StatusNotFound, "song not found")
+	}
+	if err != nil {
+		h.log(c).Error("failed to get song", zap.Uint("id", id), zap.Error(err))
+		return h.internalError(c, err)
+	}
+
+	type successResponse struct {
+		ID       uint                    `json:"id" example:"1"`
+		Status   models.EnrichmentStatus `json:"status" example:"enriched" swaggertype:"string"`
+		Attempts int                     `json:"attempts" example:"1"`
+		Error    string                  `json:"error,omitempty" example:""`
+	}
+	h.log(c).Info("retrieved enrichment status",
+		zap.Uint("id", id),
+		zap.String("status", string(song.EnrichmentStatus)))
+	return c.JSON(http.StatusOK, successResponse{
+		ID:       song.ID,
+		Status:   song.EnrichmentStatus,
+		Attempts: song.EnrichmentAttempts,
+		Error:    song.EnrichmentError,
+	})
+}
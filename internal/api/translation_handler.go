@@ -0,0 +1,220 @@
+package api
+
+import (
+	"errors"
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// songTranslation выбирает перевод для GetSongHandler: параметр lang задает язык явно, иначе язык
+// согласуется по Accept-Language. nil — отдать оригинал; при ошибке ответ уже записан
+func (h *SongHandler) songTranslation(c echo.Context, id uint) (*models.SongTranslation, error) {
+	c.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+	ctx := c.Request().Context()
+
+	if lang := c.QueryParam("lang"); lang != "" {
+		translation, err := h.service.GetTranslation(ctx, id, lang)
+		if err != nil {
+			return nil, h.translationError(c, err)
+		}
+		return translation, nil
+	}
+
+	header := c.Request().Header.Get("Accept-Language")
+	if header == "" {
+		return nil, nil
+	}
+	prefs, _, err := language.ParseAcceptLanguage(header)
+	if err != nil {
+		h.log(c).Debug("ignoring invalid Accept-Language", zap.String("header", header), zap.Error(err))
+		return nil, nil
+	}
+	translation, err := h.service.NegotiateTranslation(ctx, id, prefs)
+	if err != nil {
+		h.log(c).Error("failed to negotiate translation", zap.Uint("id", id), zap.Error(err))
+		return nil, h.internalError(c, err)
+	}
+	return translation, nil
+}
+
+// alignedStanzas возвращает куплеты оригинала с теми же номерами, что у куплетов или строк перевода на странице
+func alignedStanzas(original, page []lyrics.Stanza) []lyrics.Stanza {
+	indexes := make(map[int]bool, len(page))
+	for _, verse := range page {
+		indexes[verse.Index] = true
+	}
+	aligned := make([]lyrics.Stanza, 0, len(indexes))
+	for _, stanza := range original {
+		if indexes[stanza.Index] {
+			aligned = append(aligned, stanza)
+		}
+	}
+	return aligned
+}
+
+// translationError пишет ответ на ошибку работы с переводом
+func (h *SongHandler) translationError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidLanguage):
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid lang")
+	case errors.Is(err, service.ErrEmptyTranslation):
+		return errorJSON(c, http.StatusUnprocessableEntity, "text is required")
+	case errors.Is(err, service.ErrStanzaMismatch):
+		return errorJSON(c, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		h.log(c).Warn("song not found", zap.Error(err))
+		return errorJSON(c, http.StatusNotFound, "song not found")
+	case errors.Is(err, service.ErrTranslationNotFound):
+		return errorJSON(c, http.StatusNotFound, "translation not found")
+	}
+	h.log(c).Error("failed to process translation", zap.Error(err))
+	return h.internalError(c, err)
+}
+
+// @Summary Список переводов песни
+// @Description Возвращает все переводы текста песни в порядке языков
+// @Tags translations
+// @Produce json
+// @Param id path int true "song id"
+// @Success 200 {object} api.ListTranslationsHandler.successResponse "received successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/translations [get]
+func (h *SongHandler) ListTranslationsHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	translations, err := h.service.ListTranslations(c.Request().Context(), id)
+	if err != nil {
+		return h.translationError(c, err)
+	}
+
+	type successResponse struct {
+		Translations []models.SongTranslation `json:"translations"`
+	}
+	return c.JSON(http.StatusOK, successResponse{Translations: translations})
+}
+
+// @Summary Перевод песни
+// @Description Возвращает перевод текста песни на язык lang
+// @Tags translations
+// @Produce json
+// @Param id path int true "song id"
+// @Param lang path string true "тег языка BCP 47" example(ru)
+// @Success 200 {object} models.SongTranslation "received successfully"
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 404 {object} ErrorResponse "translation not found" example:{"error": "translation not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid lang" example:{"error": "invalid lang"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/translations/{lang} [get]
+func (h *SongHandler) GetTranslationHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	translation, err := h.service.GetTranslation(c.Request().Context(), id, c.Param("lang"))
+	if err != nil {
+		return h.translationError(c, err)
+	}
+	return c.JSON(http.StatusOK, translation)
+}
+
+// @Summary Создание или замена перевода
+// @Description Сохраняет перевод текста песни на язык lang. Куплеты разделяются пустыми строками,
+// @Description их число должно совпадать с оригиналом, чтобы куплеты перевода и оригинала шли с одинаковыми номерами
+// @Tags translations
+// @Accept json
+// @Produce json
+// @Param id path int true "song id"
+// @Param lang path string true "тег языка BCP 47" example(ru)
+// @Param translation body api.PutTranslationHandler.translationRequest true "текст перевода"
+// @Success 200 {object} models.SongTranslation "replaced successfully"
+// @Success 201 {object} models.SongTranslation "created successfully"
+// @Failure 400 {object} ErrorResponse "invalid request" example:{"error": "invalid request"}
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid lang" example:{"error": "invalid lang"}
+// @Failure 422 {object} ErrorResponse "text is required" example:{"error": "text is required"}
+// @Failure 422 {object} ErrorResponse "stanza mismatch" example:{"error": "translation stanzas do not match the original: translation has 3 stanzas, original has 4"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/translations/{lang} [put]
+func (h *SongHandler) PutTranslationHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+
+	type translationRequest struct {
+		Text string `json:"text" example:"О, детка, разве ты не знаешь, что я страдаю?\n..."`
+	}
+	var req translationRequest
+	if err = c.Bind(&req); err != nil {
+		h.log(c).Debug("failed to bind translation", zap.Error(err))
+		return errorJSON(c, http.StatusBadRequest, "invalid request")
+	}
+	h.log(c).Debug("starting put translation",
+		zap.Uint("id", id),
+		zap.String("lang", c.Param("lang")))
+
+	translation, created, err := h.service.PutTranslation(c.Request().Context(), id, c.Param("lang"), req.Text)
+	if err != nil {
+		return h.translationError(c, err)
+	}
+	if created {
+		return c.JSON(http.StatusCreated, translation)
+	}
+	return c.JSON(http.StatusOK, translation)
+}
+
+// @Summary Удаление перевода
+// @Description Удаляет перевод текста песни на язык lang
+// @Tags translations
+// @Produce json
+// @Param id path int true "song id"
+// @Param lang path string true "тег языка BCP 47" example(ru)
+// @Success 200 {object} api.DeleteTranslationHandler.successResponse "deleted successfully" example:{"success": true}
+// @Failure 404 {object} ErrorResponse "song not found" example:{"error": "song not found"}
+// @Failure 404 {object} ErrorResponse "translation not found" example:{"error": "translation not found"}
+// @Failure 422 {object} ErrorResponse "invalid id" example:{"error": "invalid id"}
+// @Failure 422 {object} ErrorResponse "invalid lang" example:{"error": "invalid lang"}
+// @Failure 500 {object} ErrorResponse "internal server error" example:{"error": "internal server error"}
+// @Failure 504 {object} ErrorResponse "request timeout" example:{"error": "request timeout"}
+// @Router /{id}/translations/{lang} [delete]
+func (h *SongHandler) DeleteTranslationHandler(c echo.Context) error {
+	id, err := parseID(c)
+	if err != nil {
+		h.log(c).Warn("failed to parse song id",
+			zap.String("id", c.Param("id")),
+			zap.Error(err))
+		return errorJSON(c, http.StatusUnprocessableEntity, "invalid id")
+	}
+	if err = h.service.DeleteTranslation(c.Request().Context(), id, c.Param("lang")); err != nil {
+		return h.translationError(c, err)
+	}
+
+	type successResponse struct {
+		Success bool `json:"success" example:"true"`
+	}
+	return c.JSON(http.StatusOK, successResponse{true})
+}
@@ -2,15 +2,52 @@ package config
 
 import (
 	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/jaam8/online_song_library/internal/tracing"
 	"github.com/jaam8/online_song_library/pkg/postgres"
 	"github.com/joho/godotenv"
+	"time"
 )
 
 type Config struct {
-	RestPort   string          `yaml:"REST_PORT" env:"REST_PORT" env-default:"8080"`
-	SwaggerUrl string          `yaml:"SWAGGER_URL" env:"SWAGGER_URL"`
-	LogLevel   string          `yaml:"LOG_LEVEL" env:"LOG_LEVEL" env-default:"debug"`
-	Postgres   postgres.Config `yaml:"POSTGRES" env:"POSTGRES"`
+	RestPort   string                   `yaml:"REST_PORT" env:"REST_PORT" env-default:"8080"`
+	Swagger    service.InfoClientConfig `yaml:"SWAGGER" env:"SWAGGER"`
+	Providers  service.ProvidersConfig  `yaml:"PROVIDERS" env:"PROVIDERS"`
+	Enrichment service.EnrichmentConfig `yaml:"ENRICHMENT" env:"ENRICHMENT"`
+	Cache      service.CacheConfig      `yaml:"CACHE" env:"CACHE"`
+	Search     service.SearchConfig     `yaml:"SEARCH" env:"SEARCH"`
+	LogLevel   string                   `yaml:"LOG_LEVEL" env:"LOG_LEVEL" env-default:"debug"`
+	Storage    string                   `yaml:"STORAGE" env:"STORAGE" env-default:"postgres"`
+	Postgres   postgres.Config          `yaml:"POSTGRES" env:"POSTGRES"`
+	Timeouts   TimeoutsConfig           `yaml:"TIMEOUTS" env:"TIMEOUTS"`
+	Shutdown   ShutdownConfig           `yaml:"SHUTDOWN" env:"SHUTDOWN"`
+	Health     HealthConfig             `yaml:"HEALTH" env:"HEALTH"`
+	Tracing    tracing.Config           `yaml:"TRACING" env:"TRACING"`
+}
+
+// TimeoutsConfig дедлайны обработки запросов по маршрутам
+type TimeoutsConfig struct {
+	Create  time.Duration `yaml:"TIMEOUT_CREATE" env:"TIMEOUT_CREATE" env-default:"30s"`
+	List    time.Duration `yaml:"TIMEOUT_LIST" env:"TIMEOUT_LIST" env-default:"5s"`
+	Get     time.Duration `yaml:"TIMEOUT_GET" env:"TIMEOUT_GET" env-default:"5s"`
+	Update  time.Duration `yaml:"TIMEOUT_UPDATE" env:"TIMEOUT_UPDATE" env-default:"5s"`
+	Delete  time.Duration `yaml:"TIMEOUT_DELETE" env:"TIMEOUT_DELETE" env-default:"5s"`
+	Refresh time.Duration `yaml:"TIMEOUT_REFRESH" env:"TIMEOUT_REFRESH" env-default:"30s"`
+	Admin   time.Duration `yaml:"TIMEOUT_ADMIN" env:"TIMEOUT_ADMIN" env-default:"10s"`
+}
+
+type ShutdownConfig struct {
+	// GracePeriod время на завершение текущих запросов и фоновых задач
+	GracePeriod time.Duration `yaml:"SHUTDOWN_GRACE_PERIOD" env:"SHUTDOWN_GRACE_PERIOD" env-default:"30s"`
+	// ReadinessDelay пауза между снятием готовности и остановкой сервера
+	ReadinessDelay time.Duration `yaml:"SHUTDOWN_READINESS_DELAY" env:"SHUTDOWN_READINESS_DELAY" env-default:"5s"`
+}
+
+type HealthConfig struct {
+	// CheckTimeout дедлайн одной проверки зависимости
+	CheckTimeout time.Duration `yaml:"HEALTH_CHECK_TIMEOUT" env:"HEALTH_CHECK_TIMEOUT" env-default:"2s"`
+	// CheckInfo учитывать доступность API /info в /readyz
+	CheckInfo bool `yaml:"HEALTH_CHECK_INFO" env:"HEALTH_CHECK_INFO" env-default:"false"`
 }
 
 func New() (*Config, error) {
@@ -0,0 +1,89 @@
+package lyrics
+
+import "strings"
+
+// DiffOp операция построчного сравнения
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffLine строка построчного сравнения; OldLine и NewLine — номера строк в старом и новом тексте,
+// считая пустые, у вставленной строки нет OldLine, у удаленной — NewLine
+type DiffLine struct {
+	Op      DiffOp `json:"op" example:"insert" swaggertype:"string"`
+	Text    string `json:"text" example:"Ooh baby, can you hear me moan?"`
+	OldLine int    `json:"old_line,omitempty" example:"2"`
+	NewLine int    `json:"new_line,omitempty" example:"3"`
+}
+
+// DiffLines сравнивает тексты построчно по наибольшей общей подпоследовательности строк
+func DiffLines(oldText, newText string) []DiffLine {
+	a, b := splitLines(oldText), splitLines(newText)
+
+	// общие начало и конец не участвуют в поиске подпоследовательности
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] — длина общей подпоследовательности midA[i:] и midB[j:]
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, len(a)+len(b)-prefix-suffix)
+	for i := 0; i < prefix; i++ {
+		diff = append(diff, DiffLine{Op: DiffEqual, Text: a[i], OldLine: i + 1, NewLine: i + 1})
+	}
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: midA[i], OldLine: prefix + i + 1, NewLine: prefix + j + 1})
+			i++
+			j++
+		case i < len(midA) && (j == len(midB) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: midA[i], OldLine: prefix + i + 1})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: midB[j], NewLine: prefix + j + 1})
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		diff = append(diff, DiffLine{
+			Op:      DiffEqual,
+			Text:    a[len(a)-suffix+k],
+			OldLine: len(a) - suffix + k + 1,
+			NewLine: len(b) - suffix + k + 1,
+		})
+	}
+	return diff
+}
+
+func splitLines(text string) []string {
+	text = strings.TrimRight(Normalize(text), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,100 @@
+package lyrics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []DiffLine
+	}{
+		{
+			name: "identical texts",
+			old:  "a\nb",
+			new:  "a\r\nb\n",
+			want: []DiffLine{
+				{Op: DiffEqual, Text: "a", OldLine: 1, NewLine: 1},
+				{Op: DiffEqual, Text: "b", OldLine: 2, NewLine: 2},
+			},
+		},
+		{
+			name: "text added to empty song",
+			old:  "",
+			new:  "a\nb",
+			want: []DiffLine{
+				{Op: DiffInsert, Text: "a", NewLine: 1},
+				{Op: DiffInsert, Text: "b", NewLine: 2},
+			},
+		},
+		{
+			name: "changed line is a delete followed by an insert",
+			old:  "a\nb\n\nc",
+			new:  "a\nB\n\nc\nd",
+			want: []DiffLine{
+				{Op: DiffEqual, Text: "a", OldLine: 1, NewLine: 1},
+				{Op: DiffDelete, Text: "b", OldLine: 2},
+				{Op: DiffInsert, Text: "B", NewLine: 2},
+				{Op: DiffEqual, Text: "", OldLine: 3, NewLine: 3},
+				{Op: DiffEqual, Text: "c", OldLine: 4, NewLine: 4},
+				{Op: DiffInsert, Text: "d", NewLine: 5},
+			},
+		},
+		{
+			name: "removed stanza",
+			old:  "a\n\nb\nc\n\nd",
+			new:  "a\n\nd",
+			want: []DiffLine{
+				{Op: DiffEqual, Text: "a", OldLine: 1, NewLine: 1},
+				{Op: DiffEqual, Text: "", OldLine: 2, NewLine: 2},
+				{Op: DiffDelete, Text: "b", OldLine: 3},
+				{Op: DiffDelete, Text: "c", OldLine: 4},
+				{Op: DiffDelete, Text: "", OldLine: 5},
+				{Op: DiffEqual, Text: "d", OldLine: 6, NewLine: 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffLines(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDiffLinesRebuildsTexts проверяет, что из диффа восстанавливаются оба текста с верными номерами строк
+func TestDiffLinesRebuildsTexts(t *testing.T) {
+	pairs := [][2]string{
+		{"x\ny\nz\nx\ny", "y\nx\nz\ny\nx\nq"},
+		{"chorus\nverse\nchorus\nverse\nchorus", "verse\nchorus\nbridge\nchorus"},
+		{"a\nb\nc", ""},
+	}
+	for _, p := range pairs {
+		var oldLines, newLines []string
+		for _, line := range DiffLines(p[0], p[1]) {
+			if line.Op != DiffInsert {
+				oldLines = append(oldLines, line.Text)
+				if line.OldLine != len(oldLines) {
+					t.Errorf("%q: old line number %d, want %d", p, line.OldLine, len(oldLines))
+				}
+			}
+			if line.Op != DiffDelete {
+				newLines = append(newLines, line.Text)
+				if line.NewLine != len(newLines) {
+					t.Errorf("%q: new line number %d, want %d", p, line.NewLine, len(newLines))
+				}
+			}
+		}
+		if got := strings.Join(oldLines, "\n"); got != p[0] {
+			t.Errorf("old text rebuilt as %q, want %q", got, p[0])
+		}
+		if got := strings.Join(newLines, "\n"); got != p[1] {
+			t.Errorf("new text rebuilt as %q, want %q", got, p[1])
+		}
+	}
+}
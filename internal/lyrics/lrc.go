@@ -0,0 +1,199 @@
+package lyrics
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLRC = errors.New("invalid lrc")
+
+// SyncedLine строка синхронизированного текста. StartMs — время начала от начала трека,
+// EndMs — начало следующей строки (у последней строки не задано), Line — номер строки в тексте песни
+// без учета пустых, если строку удалось сопоставить
+type SyncedLine struct {
+	StartMs int64  `json:"start_ms" example:"12340"`
+	EndMs   int64  `json:"end_ms,omitempty" example:"15800"`
+	Text    string `json:"text" example:"Ooh baby, don't you know I suffer?"`
+	Line    int    `json:"line,omitempty" example:"1"`
+}
+
+// Synced синхронизированный текст песни: строки по возрастанию времени
+type Synced struct {
+	Lines []SyncedLine `json:"lines"`
+}
+
+// Value сохраняет текст в колонку JSONB
+func (s Synced) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan читает текст из колонки JSONB
+func (s *Synced) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case nil:
+		*s = Synced{}
+		return nil
+	}
+	return fmt.Errorf("unsupported synced lyrics column type %T", src)
+}
+
+var (
+	// lrcTimeRe метка времени [mm:ss], [mm:ss.xx] или [mm:ss.xxx]
+	lrcTimeRe = regexp.MustCompile(`^\[(\d{1,3}):([0-5]\d)(?:[.:](\d{1,3}))?\]`)
+	// lrcTagRe служебный тег вроде [ar:Muse] или [offset:+250]
+	lrcTagRe = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+)
+
+// ParseLRC разбирает текст в формате LRC. Строки с одной меткой должны идти по возрастанию времени.
+// Строка может иметь несколько меток (сжатый LRC, [00:12.00][01:30.00]припев): такие строки
+// разворачиваются и встают между остальными по времени; одна и та же метка у двух строк считается ошибкой.
+// Тег offset сдвигает все метки: положительный — раньше. Остальные теги (ar, ti, al, by, length) игнорируются
+func ParseLRC(text string) (*Synced, error) {
+	var (
+		lines  []SyncedLine
+		offset int64
+		last   int64 = -1
+	)
+	for n, raw := range strings.Split(Normalize(text), "\n") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if m := lrcTagRe.FindStringSubmatch(raw); m != nil && !lrcTimeRe.MatchString(raw) {
+			if strings.EqualFold(m[1], "offset") {
+				v, err := strconv.ParseInt(strings.TrimSpace(m[2]), 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: bad offset %q", ErrInvalidLRC, n+1, m[2])
+				}
+				offset = v
+			}
+			continue
+		}
+
+		var stamps []int64
+		for {
+			m := lrcTimeRe.FindStringSubmatch(raw)
+			if m == nil {
+				break
+			}
+			stamps = append(stamps, lrcMillis(m[1], m[2], m[3]))
+			raw = raw[len(m[0]):]
+		}
+		if len(stamps) == 0 {
+			return nil, fmt.Errorf("%w: line %d: missing timestamp", ErrInvalidLRC, n+1)
+		}
+		if len(stamps) == 1 {
+			if stamps[0] <= last {
+				return nil, fmt.Errorf("%w: line %d: timestamp %s is not after %s",
+					ErrInvalidLRC, n+1, formatLRCTime(stamps[0]), formatLRCTime(last))
+			}
+			last = stamps[0]
+		}
+		for _, ms := range stamps {
+			lines = append(lines, SyncedLine{StartMs: ms, Text: strings.TrimSpace(raw)})
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: no timed lines", ErrInvalidLRC)
+	}
+	// строки с одной меткой уже упорядочены, сортировка переставляет только развернутые строки сжатого LRC
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].StartMs < lines[j].StartMs })
+	for i := 1; i < len(lines); i++ {
+		if lines[i].StartMs == lines[i-1].StartMs {
+			return nil, fmt.Errorf("%w: duplicate timestamp %s", ErrInvalidLRC, formatLRCTime(lines[i].StartMs))
+		}
+	}
+	for i := range lines {
+		lines[i].StartMs = max(lines[i].StartMs-offset, 0)
+	}
+	if offset > 0 && len(lines) > 1 && lines[0].StartMs == lines[1].StartMs {
+		return nil, fmt.Errorf("%w: offset %d moves several lines before the start", ErrInvalidLRC, offset)
+	}
+	return &Synced{Lines: lines}, nil
+}
+
+// lrcMillis переводит минуты, секунды и дробную часть метки в миллисекунды; .5 — это 500 мс, .05 — 50 мс
+func lrcMillis(min, sec, frac string) int64 {
+	m, _ := strconv.ParseInt(min, 10, 64)
+	s, _ := strconv.ParseInt(sec, 10, 64)
+	ms := (m*60 + s) * 1000
+	if frac != "" {
+		f, _ := strconv.ParseInt((frac + "00")[:3], 10, 64)
+		ms += f
+	}
+	return ms
+}
+
+func formatLRCTime(ms int64) string {
+	if ms%10 != 0 {
+		return fmt.Sprintf("%02d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+	}
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// LRC собирает текст в формате LRC, по одной метке на строку
+func (s *Synced) LRC() string {
+	var b strings.Builder
+	for _, line := range s.Lines {
+		b.WriteString("[" + formatLRCTime(line.StartMs) + "]" + line.Text + "\n")
+	}
+	return b.String()
+}
+
+// Timed возвращает копию строк с концом каждой строки и номерами строк текста песни text.
+// Строки сопоставляются по порядку без учета регистра и пробелов, поэтому повторы припева
+// попадают на свое вхождение в тексте
+func (s *Synced) Timed(text string) []SyncedLine {
+	var textLines []string
+	for _, stanza := range Parse(text) {
+		for _, line := range stanza.Lines {
+			textLines = append(textLines, contentKey([]string{line}))
+		}
+	}
+
+	lines := make([]SyncedLine, len(s.Lines))
+	next := 0
+	for i, line := range s.Lines {
+		line.EndMs, line.Line = 0, 0
+		if i+1 < len(s.Lines) {
+			line.EndMs = s.Lines[i+1].StartMs
+		}
+		key := contentKey([]string{line.Text})
+		for j := next; key != "" && j < len(textLines); j++ {
+			if textLines[j] == key {
+				line.Line, next = j+1, j+1
+				break
+			}
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// Matches сообщает, что каждая непустая строка синхронизированного текста сопоставляется со строкой text,
+// то есть метки времени по-прежнему относятся к этому тексту
+func (s *Synced) Matches(text string) bool {
+	for _, line := range s.Timed(text) {
+		if contentKey([]string{line.Text}) != "" && line.Line == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// At возвращает индекс строки, которая звучит в момент t, или -1, если t раньше первой строки
+func (s *Synced) At(t time.Duration) int {
+	ms := t.Milliseconds()
+	return sort.Search(len(s.Lines), func(i int) bool { return s.Lines[i].StartMs > ms }) - 1
+}
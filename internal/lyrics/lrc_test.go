@@ -0,0 +1,133 @@
+package lyrics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseLRC(t *testing.T) {
+	tests := []struct {
+		name string
+		lrc  string
+		want []SyncedLine
+	}{
+		{
+			name: "fraction formats",
+			lrc:  "[ar:Muse]\n[00:01]one\n[00:02.5]two\n[00:03.05]three\n[00:04.123]four",
+			want: []SyncedLine{
+				{StartMs: 1000, Text: "one"},
+				{StartMs: 2500, Text: "two"},
+				{StartMs: 3050, Text: "three"},
+				{StartMs: 4123, Text: "four"},
+			},
+		},
+		{
+			name: "compressed lines are ordered by time",
+			lrc:  "[00:12.00][01:30.00]chorus\n[00:20.00]verse\n[01:40.00]outro",
+			want: []SyncedLine{
+				{StartMs: 12000, Text: "chorus"},
+				{StartMs: 20000, Text: "verse"},
+				{StartMs: 90000, Text: "chorus"},
+				{StartMs: 100000, Text: "outro"},
+			},
+		},
+		{
+			name: "offset moves lines earlier",
+			lrc:  "[offset:+500]\r\n[00:00.20]intro\r\n[00:01.00]one",
+			want: []SyncedLine{
+				{StartMs: 0, Text: "intro"},
+				{StartMs: 500, Text: "one"},
+			},
+		},
+		{
+			name: "empty line marks instrumental break",
+			lrc:  "[00:01.00]one\n[00:05.00]",
+			want: []SyncedLine{
+				{StartMs: 1000, Text: "one"},
+				{StartMs: 5000, Text: ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			synced, err := ParseLRC(tt.lrc)
+			if err != nil {
+				t.Fatalf("ParseLRC: %v", err)
+			}
+			if !reflect.DeepEqual(synced.Lines, tt.want) {
+				t.Errorf("lines = %+v, want %+v", synced.Lines, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLRCErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		lrc  string
+	}{
+		{name: "no timed lines", lrc: "[ar:Muse]\n[ti:Uprising]"},
+		{name: "line without timestamp", lrc: "[00:01.00]one\ntwo"},
+		{name: "duplicate timestamp", lrc: "[00:01.00][00:05.00]one\n[00:05.00]two"},
+		{name: "out of order lines", lrc: "[00:05.00]one\n[00:04.00]two"},
+		{name: "out of order around compressed line", lrc: "[00:10.00]one\n[00:12.00][01:30.00]chorus\n[00:09.00]two"},
+		{name: "bad offset", lrc: "[offset:soon]\n[00:01.00]one"},
+		{name: "offset collapses lines", lrc: "[offset:5000]\n[00:01.00]one\n[00:02.00]two"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseLRC(tt.lrc); !errors.Is(err, ErrInvalidLRC) {
+				t.Errorf("expected ErrInvalidLRC, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSyncedTimedAndAt(t *testing.T) {
+	synced, err := ParseLRC("[00:01.00]Chorus line\n[00:05.00]Verse line\n[00:09.00]chorus  LINE")
+	if err != nil {
+		t.Fatalf("ParseLRC: %v", err)
+	}
+	text := "Chorus line\n\nVerse line\n\nChorus line"
+
+	timed := synced.Timed(text)
+	wantLines := []int{1, 2, 3}
+	wantEnds := []int64{5000, 9000, 0}
+	for i, line := range timed {
+		if line.Line != wantLines[i] || line.EndMs != wantEnds[i] {
+			t.Errorf("line %d = %+v, want line %d end %d", i, line, wantLines[i], wantEnds[i])
+		}
+	}
+
+	for _, tt := range []struct {
+		t    time.Duration
+		want int
+	}{
+		{t: 500 * time.Millisecond, want: -1},
+		{t: time.Second, want: 0},
+		{t: 8 * time.Second, want: 1},
+		{t: time.Minute, want: 2},
+	} {
+		if got := synced.At(tt.t); got != tt.want {
+			t.Errorf("At(%s) = %d, want %d", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestSyncedMatches(t *testing.T) {
+	synced, err := ParseLRC("[00:01.00]first line\n[00:03.00]\n[00:05.00]second line")
+	if err != nil {
+		t.Fatalf("ParseLRC: %v", err)
+	}
+	if !synced.Matches("First line\n\nSecond  line\nthird line") {
+		t.Error("lyrics with the same lines should match")
+	}
+	if synced.Matches("First line\n\nSecond line, fixed") {
+		t.Error("changed line should not match")
+	}
+	if synced.Matches("second line\nfirst line") {
+		t.Error("reordered lines should not match")
+	}
+}
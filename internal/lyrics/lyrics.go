@@ -0,0 +1,81 @@
+package lyrics
+
+import (
+	"errors"
+	"strings"
+)
+
+// Mode единица пагинации текста песни
+type Mode string
+
+const (
+	// ModeStanza пагинация по куплетам, разделенным пустыми строками
+	ModeStanza Mode = "stanza"
+	// ModeLine пагинация по отдельным строкам
+	ModeLine Mode = "line"
+)
+
+var ErrUnknownMode = errors.New("unknown lyrics mode")
+
+// ParseMode разбирает режим пагинации; пустая строка — ModeStanza
+func ParseMode(raw string) (Mode, error) {
+	switch Mode(raw) {
+	case "", ModeStanza:
+		return ModeStanza, nil
+	case ModeLine:
+		return ModeLine, nil
+	}
+	return "", ErrUnknownMode
+}
+
+// Stanza куплет или, в режиме ModeLine, одна его строка. Index — номер куплета, FirstLine и LastLine —
+// номера строк в песне без учета пустых; все номера начинаются с 1
+type Stanza struct {
+	Index     int      `json:"stanza" example:"1"`
+	FirstLine int      `json:"first_line" example:"1"`
+	LastLine  int      `json:"last_line" example:"4"`
+	Lines     []string `json:"lines" example:"Ooh baby don't you know I suffer?,Ooh baby can you hear me moan?"`
+}
+
+// Normalize приводит переводы строк к \n
+func Normalize(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+// Parse делит текст на куплеты по пустым строкам. Пробелы в конце строк отбрасываются,
+// несколько пустых строк подряд считаются одним разделителем
+func Parse(text string) []Stanza {
+	stanzas := make([]Stanza, 0)
+	line := 0
+	var current *Stanza
+	for _, raw := range strings.Split(Normalize(text), "\n") {
+		raw = strings.TrimRight(raw, " \t")
+		if strings.TrimSpace(raw) == "" {
+			current = nil
+			continue
+		}
+		line++
+		if current == nil {
+			stanzas = append(stanzas, Stanza{Index: len(stanzas) + 1, FirstLine: line})
+			current = &stanzas[len(stanzas)-1]
+		}
+		current.Lines = append(current.Lines, raw)
+		current.LastLine = line
+	}
+	return stanzas
+}
+
+// Split возвращает единицы пагинации для режима: куплеты как есть или каждую строку отдельно
+func Split(stanzas []Stanza, mode Mode) []Stanza {
+	if mode != ModeLine {
+		return stanzas
+	}
+	lines := make([]Stanza, 0)
+	for _, s := range stanzas {
+		for i, text := range s.Lines {
+			n := s.FirstLine + i
+			lines = append(lines, Stanza{Index: s.Index, FirstLine: n, LastLine: n, Lines: []string{text}})
+		}
+	}
+	return lines
+}
@@ -0,0 +1,170 @@
+package lyrics
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SectionType тип куплета в структурированном тексте
+type SectionType string
+
+const (
+	SectionVerse     SectionType = "verse"
+	SectionChorus    SectionType = "chorus"
+	SectionPreChorus SectionType = "pre_chorus"
+	SectionBridge    SectionType = "bridge"
+	SectionIntro     SectionType = "intro"
+	SectionOutro     SectionType = "outro"
+)
+
+// Section уникальный куплет; повторы припева хранятся один раз и несколько раз упоминаются в Order
+type Section struct {
+	ID    string      `json:"id" example:"chorus-1"`
+	Type  SectionType `json:"type" example:"chorus" swaggertype:"string"`
+	Lines []string    `json:"lines" example:"Supermassive black hole"`
+}
+
+// Structured структурированный текст песни: уникальные куплеты и порядок их исполнения
+type Structured struct {
+	Sections []Section `json:"sections"`
+	Order    []string  `json:"order" example:"verse-1,chorus-1,verse-2,chorus-1"`
+}
+
+// Value сохраняет текст в колонку JSONB
+func (s Structured) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan читает текст из колонки JSONB
+func (s *Structured) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	case nil:
+		*s = Structured{}
+		return nil
+	}
+	return fmt.Errorf("unsupported lyrics column type %T", src)
+}
+
+// Text собирает плоский текст в порядке исполнения, куплеты разделяются пустой строкой
+func (s *Structured) Text() string {
+	sections := make(map[string]Section, len(s.Sections))
+	for _, section := range s.Sections {
+		sections[section.ID] = section
+	}
+	stanzas := make([]string, 0, len(s.Order))
+	for _, id := range s.Order {
+		stanzas = append(stanzas, strings.Join(sections[id].Lines, "\n"))
+	}
+	return strings.Join(stanzas, "\n\n")
+}
+
+// labelRe метка куплета в первой строке: [Chorus], Verse 2:, (Припев x2), [Chorus: Freddie Mercury]
+var labelRe = regexp.MustCompile(`(?i)^[\[(]?\s*(pre[- ]?chorus|предприпев|verse|куплет|chorus|refrain|hook|припев|bridge|бридж|intro|вступление|outro|концовка)\s*\d*\s*(?::[^\])]*)?[\])]?\s*:?\s*(?:\(?\s*[x×х]\s*(\d+)\s*\)?)?$`)
+
+var labelTypes = map[string]SectionType{
+	"verse":      SectionVerse,
+	"куплет":     SectionVerse,
+	"chorus":     SectionChorus,
+	"refrain":    SectionChorus,
+	"hook":       SectionChorus,
+	"припев":     SectionChorus,
+	"prechorus":  SectionPreChorus,
+	"предприпев": SectionPreChorus,
+	"bridge":     SectionBridge,
+	"бридж":      SectionBridge,
+	"intro":      SectionIntro,
+	"вступление": SectionIntro,
+	"outro":      SectionOutro,
+	"концовка":   SectionOutro,
+}
+
+// parseLabel распознает метку куплета и число повторов из x2
+func parseLabel(line string) (SectionType, int, bool) {
+	m := labelRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", 0, false
+	}
+	name := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(m[1]))
+	repeat := 1
+	if m[2] != "" {
+		repeat, _ = strconv.Atoi(m[2])
+	}
+	return labelTypes[name], max(repeat, 1), true
+}
+
+// Structure эвристически строит структуру из плоского текста. Куплеты делятся пустыми строками;
+// метка в первой строке ([Chorus], Куплет 2:) задает тип, а метка без строк ссылается на последний
+// куплет этого типа. Одинаковые куплеты хранятся один раз; без метки повторяющийся куплет считается
+// припевом, остальные — обычными куплетами
+func Structure(text string) *Structured {
+	type block struct {
+		typ    SectionType
+		repeat int
+		lines  []string
+	}
+	blocks := make([]block, 0)
+	counts := make(map[string]int)
+	for _, stanza := range Parse(text) {
+		b := block{repeat: 1, lines: stanza.Lines}
+		if typ, repeat, ok := parseLabel(stanza.Lines[0]); ok {
+			b.typ, b.repeat, b.lines = typ, repeat, stanza.Lines[1:]
+		}
+		if len(b.lines) > 0 {
+			counts[contentKey(b.lines)]++
+		}
+		blocks = append(blocks, b)
+	}
+
+	s := &Structured{Sections: make([]Section, 0), Order: make([]string, 0)}
+	byContent := make(map[string]string)
+	idTypes := make(map[string]SectionType)
+	lastByType := make(map[SectionType]string)
+	typeCount := make(map[SectionType]int)
+	for _, b := range blocks {
+		var id string
+		key := contentKey(b.lines)
+		switch {
+		case len(b.lines) == 0:
+			if id = lastByType[b.typ]; id == "" {
+				continue
+			}
+		case byContent[key] != "":
+			id = byContent[key]
+		default:
+			typ := b.typ
+			if typ == "" {
+				typ = SectionVerse
+				if counts[key] > 1 {
+					typ = SectionChorus
+				}
+			}
+			typeCount[typ]++
+			id = fmt.Sprintf("%s-%d", typ, typeCount[typ])
+			s.Sections = append(s.Sections, Section{ID: id, Type: typ, Lines: b.lines})
+			byContent[key] = id
+			idTypes[id] = typ
+		}
+		lastByType[idTypes[id]] = id
+		for range b.repeat {
+			s.Order = append(s.Order, id)
+		}
+	}
+	return s
+}
+
+// contentKey ключ сравнения куплетов без учета регистра и пробелов
+func contentKey(lines []string) string {
+	normalized := make([]string, 0, len(lines))
+	for _, line := range lines {
+		normalized = append(normalized, strings.Join(strings.Fields(strings.ToLower(line)), " "))
+	}
+	return strings.Join(normalized, "\n")
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "song_library"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by repository method.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method", "operation", "status"})
+
+	InfoRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "info_requests_total",
+		Help:      "Number of music info API calls by provider and outcome, including retries.",
+	}, []string{"provider", "outcome"})
+
+	InfoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "info_request_duration_seconds",
+		Help:      "Music info API call latency by provider and outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider", "outcome"})
+)
+
+// Исходы запроса к API /info
+const (
+	OutcomeOK          = "ok"
+	OutcomeNotFound    = "not_found"
+	OutcomeClientError = "client_error"
+	OutcomeServerError = "server_error"
+	OutcomeNetwork     = "network_error"
+	OutcomeBadPayload  = "bad_payload"
+	OutcomeCanceled    = "canceled"
+	OutcomeCircuitOpen = "circuit_open"
+)
+
+// RegisterSongsGauge регистрирует gauge с числом песен в библиотеке;
+// count вызывается при каждом сборе метрик
+func RegisterSongsGauge(count func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "songs_total",
+		Help:      "Total number of songs in the library.",
+	}, count)
+}
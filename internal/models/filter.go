@@ -0,0 +1,35 @@
+package models
+
+// FilterOp оператор фильтра списка песен
+type FilterOp string
+
+const (
+	FilterEq   FilterOp = "eq"
+	FilterLike FilterOp = "like"
+	FilterGTE  FilterOp = "gte"
+	FilterLTE  FilterOp = "lte"
+)
+
+// SongFilter условие фильтрации списка песен. Несколько значений объединяются через OR
+// (для eq — IN), Negate инвертирует условие целиком
+type SongFilter struct {
+	Field  string
+	Op     FilterOp
+	Values []interface{}
+	Negate bool
+}
+
+// SortField поле сортировки списка песен
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// SongPage страница списка песен при пагинации курсором. Next и Prev — непрозрачные курсоры
+// соседних страниц, пустые, если страницы нет; Total заполняется только по запросу
+type SongPage struct {
+	Songs []Song
+	Next  string
+	Prev  string
+	Total *int64
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"time"
+)
+
+// SongRevision снимок полей песни после изменения: кто, когда и зачем его сделал
+type SongRevision struct {
+	ID          uint      `json:"id" example:"12" gorm:"primaryKey"`
+	SongID      uint      `json:"song_id" example:"1"`
+	Author      string    `json:"author" example:"editor"`
+	Reason      string    `json:"reason" example:"fix typo in the second verse"`
+	CreatedAt   time.Time `json:"created_at" example:"2025-03-01T12:00:00Z"`
+	Group       string    `json:"group" example:"Muse"`
+	Song        string    `json:"song" example:"Supermassive Black Hole"`
+	ReleaseDate time.Time `json:"release_date" example:"2006-06-19T00:00:00Z"`
+	Text        string    `json:"text" example:"Ooh baby, don't you know I suffer?\n..."`
+	Link        string    `json:"link" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+}
+
+// SameContent сообщает, что у песни те же значения полей, что в ревизии
+func (r *SongRevision) SameContent(song *Song) bool {
+	return r.Group == song.Group &&
+		r.Song == song.Song &&
+		r.ReleaseDate.Equal(song.ReleaseDate) &&
+		r.Text == song.Text &&
+		r.Link == song.Link
+}
+
+// RevisionDiff различия между двумя ревизиями песни: изменения полей, кроме текста, и построчный дифф текста.
+// Source изменения поля — автор ревизии To
+type RevisionDiff struct {
+	From   uint              `json:"from" example:"10"`
+	To     uint              `json:"to" example:"12"`
+	Fields []FieldChange     `json:"fields"`
+	Lines  []lyrics.DiffLine `json:"lines"`
+}
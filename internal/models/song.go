@@ -1,6 +1,31 @@
 package models
 
-import "time"
+import (
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"time"
+)
+
+// EnrichmentStatus состояние обогащения песни данными из внешнего API
+type EnrichmentStatus string
+
+const (
+	EnrichmentPending  EnrichmentStatus = "pending"
+	EnrichmentEnriched EnrichmentStatus = "enriched"
+	EnrichmentFailed   EnrichmentStatus = "failed"
+)
+
+const (
+	SourceManual   = "manual"
+	SourceUpstream = "upstream"
+)
+
+// SongSources откуда взято значение каждого из обогащаемых полей песни:
+// manual или имя провайдера информации о песне
+type SongSources struct {
+	ReleaseDate string `json:"release_date" example:"swagger" gorm:"column:release_date_source"`
+	Text        string `json:"text" example:"fixtures" gorm:"column:text_source"`
+	Link        string `json:"link" example:"manual" gorm:"column:link_source"`
+}
 
 type Song struct {
 	ID          uint      `json:"id"  example:"1" gorm:"primaryKey"`
@@ -9,14 +34,64 @@ type Song struct {
 	ReleaseDate time.Time `json:"release_date" example:"2006-06-19T00:00:00Z"`
 	Text        string    `json:"text" example:"Ooh baby, don't you know I suffer?\n..."`
 	Link        string    `json:"link" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+
+	// Lyrics структурированный текст, собранный из Text; отдается через /songs/{id}/lyrics
+	Lyrics *lyrics.Structured `json:"-" gorm:"type:jsonb"`
+	// SyncedLyrics строки текста с метками времени из LRC; отдается через /songs/{id}/lyrics/synced
+	SyncedLyrics *lyrics.Synced `json:"-" gorm:"type:jsonb"`
+
+	Sources SongSources `json:"sources" gorm:"embedded"`
+
+	EnrichmentStatus   EnrichmentStatus `json:"enrichment_status" example:"enriched" swaggertype:"string"`
+	EnrichmentAttempts int              `json:"enrichment_attempts" example:"1"`
+	EnrichmentError    string           `json:"enrichment_error,omitempty" example:""`
+	EnrichedAt         *time.Time       `json:"enriched_at,omitempty" example:"2025-03-01T12:00:00Z"`
+}
+
+// SongSearchHit песня, найденная полнотекстовым поиском; Highlights — строки текста
+// с совпадениями, выделенными тегами <mark>
+type SongSearchHit struct {
+	Song
+	Rank       float64  `json:"rank" example:"0.6079"`
+	Highlights []string `json:"highlights" example:"<mark>Supermassive</mark> black hole"`
+}
+
+// FieldChange изменение одного обогащаемого поля песни
+type FieldChange struct {
+	Field  string `json:"field" example:"text"`
+	Old    string `json:"old" example:"Ooh baby, don't you know I suffer?"`
+	New    string `json:"new" example:"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?"`
+	Source string `json:"source" example:"swagger"`
 }
 
 // SongRaw нужен, чтобы правильно парсить ReleaseDate из json
 type SongRaw struct {
 	//ID          uint   `json:"id"`
-	Group       string `json:"group"`
-	Song        string `json:"song"`
-	ReleaseDate string `json:"release_date"`
-	Text        string `json:"text"`
-	Link        string `json:"link"`
+	Group       string `json:"group" example:"Muse"`
+	Song        string `json:"song" example:"Supermassive Black Hole"`
+	ReleaseDate string `json:"release_date" example:"16.07.2006"`
+	Text        string `json:"text" example:"Ooh baby, don't you know I suffer?\n..."`
+	Link        string `json:"link" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+}
+
+// HasDetails сообщает, что все обогащаемые поля заданы вручную и запрос во внешний API не нужен
+func (s SongRaw) HasDetails() bool {
+	return s.ReleaseDate != "" && s.Text != "" && s.Link != ""
+}
+
+// SongInfo обогащенная информация о песне, полученная от внешнего источника.
+// Поля могут быть заполнены частично, Sources хранит имя провайдера для каждого заполненного поля
+type SongInfo struct {
+	ReleaseDate time.Time
+	Text        string
+	Link        string
+	Sources     SongSources
+}
+
+// SongInfoCacheEntry закешированный ответ внешнего API, Info == nil означает, что песня не найдена
+type SongInfoCacheEntry struct {
+	Group     string
+	Song      string
+	Info      *SongInfo
+	ExpiresAt time.Time
 }
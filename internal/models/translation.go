@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// SongTranslation перевод текста песни; Language — тег BCP 47 в каноническом виде (ru, en-US).
+// Куплеты перевода разделяются пустыми строками так же, как в оригинале, и совпадают с ним по номерам
+type SongTranslation struct {
+	SongID    uint      `json:"song_id" example:"1" gorm:"primaryKey"`
+	Language  string    `json:"language" example:"ru" gorm:"primaryKey"`
+	Text      string    `json:"text" example:"О, детка, разве ты не знаешь, что я страдаю?\n..."`
+	CreatedAt time.Time `json:"created_at" example:"2025-03-01T12:00:00Z"`
+	UpdatedAt time.Time `json:"updated_at" example:"2025-03-01T12:00:00Z"`
+}
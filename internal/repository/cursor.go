@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jaam8/online_song_library/internal/models"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// songCursor позиция в списке: сортировка, для которой выдан курсор, значения полей сортировки
+// граничной песни и направление чтения. Клиенту отдается как base64 от JSON
+type songCursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+// sortKey сериализует нормализованную сортировку в вид параметра sort: -release_date,group,id
+func sortKey(sort []models.SortField) string {
+	parts := make([]string, 0, len(sort))
+	for _, f := range sort {
+		if f.Desc {
+			parts = append(parts, "-"+f.Field)
+		} else {
+			parts = append(parts, f.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// encodeCursor строит курсор на песню song: backward — страница перед ней, иначе после нее
+func encodeCursor(song *models.Song, sort []models.SortField, backward bool) string {
+	c := songCursor{Sort: sortKey(sort), Backward: backward}
+	for _, f := range sort {
+		c.Values = append(c.Values, formatValue(sortFields[f.Field].value(song)))
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// resolveCursor нормализует сортировку и разбирает курсор. Курсор хранит сортировку, для которой
+// он выдан: без параметра sort используется она, а другая сортировка в запросе считается ошибкой.
+// Для пустого курсора возвращаются nil-значения — чтение с начала списка
+func resolveCursor(sort []models.SortField, cursor string) ([]models.SortField, []interface{}, bool, error) {
+	if cursor == "" {
+		sort, err := normalizeSort(sort)
+		return sort, nil, false, err
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("%w: bad encoding", ErrInvalidCursor)
+	}
+	var c songCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, nil, false, fmt.Errorf("%w: bad payload", ErrInvalidCursor)
+	}
+
+	cursorSort := make([]models.SortField, 0)
+	for _, part := range strings.Split(c.Sort, ",") {
+		field, desc := strings.CutPrefix(part, "-")
+		cursorSort = append(cursorSort, models.SortField{Field: field, Desc: desc})
+	}
+	cursorSort, err = normalizeSort(cursorSort)
+	if err != nil || len(cursorSort) != len(c.Values) {
+		return nil, nil, false, fmt.Errorf("%w: bad payload", ErrInvalidCursor)
+	}
+	if len(sort) > 0 {
+		sort, err = normalizeSort(sort)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if !slices.Equal(sort, cursorSort) {
+			return nil, nil, false, fmt.Errorf("%w: issued for sort %s", ErrInvalidCursor, c.Sort)
+		}
+	}
+
+	values := make([]interface{}, 0, len(c.Values))
+	for i, f := range cursorSort {
+		v, err := sortFields[f.Field].parse(c.Values[i])
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("%w: bad %s value", ErrInvalidCursor, f.Field)
+		}
+		values = append(values, v)
+	}
+	return cursorSort, values, c.Backward, nil
+}
+
+// keysetSQL собирает условие "после граничной песни" в порядке сортировки (backward — "до нее").
+// Направления полей могут различаться, поэтому вместо сравнения кортежей условие раскрывается в
+// (f1 > v1) OR (f1 = v1 AND f2 > v2) OR ...
+func keysetSQL(sort []models.SortField, values []interface{}, backward bool) (string, []interface{}) {
+	var (
+		ors  []string
+		args []interface{}
+	)
+	for i, f := range sort {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, sortFields[sort[j].Field].column+" = ?")
+			args = append(args, values[j])
+		}
+		op := ">"
+		if f.Desc != backward {
+			op = "<"
+		}
+		ands = append(ands, sortFields[f.Field].column+" "+op+" ?")
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// afterKey проверяет, что песня лежит после граничной в порядке сортировки (backward — до нее),
+// так же, как условие keysetSQL
+func afterKey(song *models.Song, sort []models.SortField, values []interface{}, backward bool) bool {
+	for i, f := range sort {
+		c := compareValues(sortFields[f.Field].value(song), values[i])
+		if f.Desc {
+			c = -c
+		}
+		if backward {
+			c = -c
+		}
+		if c != 0 {
+			return c > 0
+		}
+	}
+	return false
+}
+
+// buildPage отрезает лишнюю песню, восстанавливает порядок при чтении назад и строит курсоры
+// соседних страниц. songs прочитаны с limit+1, чтобы узнать, есть ли страница дальше
+func buildPage(songs []models.Song, sort []models.SortField, limit int, fromCursor, backward bool) *models.SongPage {
+	hasMore := len(songs) > limit
+	if hasMore {
+		songs = songs[:limit]
+	}
+	if backward {
+		slices.Reverse(songs)
+	}
+
+	page := &models.SongPage{Songs: songs}
+	if len(songs) == 0 {
+		return page
+	}
+	first, last := &songs[0], &songs[len(songs)-1]
+	if backward {
+		// назад читали от страницы, которая идет следом
+		page.Next = encodeCursor(last, sort, false)
+		if hasMore {
+			page.Prev = encodeCursor(first, sort, true)
+		}
+		return page
+	}
+	if hasMore {
+		page.Next = encodeCursor(last, sort, false)
+	}
+	if fromCursor {
+		page.Prev = encodeCursor(first, sort, true)
+	}
+	return page
+}
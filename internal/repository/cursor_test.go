@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jaam8/online_song_library/internal/models"
+	"go.uber.org/zap"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	sort, err := normalizeSort([]models.SortField{{Field: "release_date", Desc: true}, {Field: "group"}})
+	if err != nil {
+		t.Fatalf("normalizeSort: %v", err)
+	}
+	song := &models.Song{
+		ID:          42,
+		Group:       "Muse, Queen",
+		ReleaseDate: time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC),
+	}
+
+	cursor := encodeCursor(song, sort, true)
+	gotSort, values, backward, err := resolveCursor(nil, cursor)
+	if err != nil {
+		t.Fatalf("resolveCursor: %v", err)
+	}
+	if !reflect.DeepEqual(gotSort, sort) {
+		t.Errorf("sort = %+v, want %+v", gotSort, sort)
+	}
+	want := []interface{}{song.ReleaseDate, song.Group, song.ID}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %#v, want %#v", values, want)
+	}
+	if !backward {
+		t.Error("backward flag was lost")
+	}
+
+	if _, _, _, err = resolveCursor([]models.SortField{{Field: "release_date", Desc: true}, {Field: "group"}}, cursor); err != nil {
+		t.Errorf("same sort in request should be accepted, got %v", err)
+	}
+}
+
+func TestResolveCursorErrors(t *testing.T) {
+	sort, _ := normalizeSort([]models.SortField{{Field: "song"}})
+	valid := encodeCursor(&models.Song{ID: 1, Song: "Uprising"}, sort, false)
+
+	tests := []struct {
+		name    string
+		sort    []models.SortField
+		cursor  string
+		wantErr error
+	}{
+		{name: "bad encoding", cursor: "!!!", wantErr: ErrInvalidCursor},
+		{name: "bad payload", cursor: "bm90IGpzb24", wantErr: ErrInvalidCursor},
+		{name: "other sort", sort: []models.SortField{{Field: "group"}}, cursor: valid, wantErr: ErrInvalidCursor},
+		{name: "invalid sort", sort: []models.SortField{{Field: "text"}}, cursor: valid, wantErr: ErrInvalidSort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, _, err := resolveCursor(tt.sort, tt.cursor); !errors.Is(err, tt.wantErr) {
+				t.Errorf("resolveCursor error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeysetSQL(t *testing.T) {
+	sort := []models.SortField{{Field: "release_date", Desc: true}, {Field: "group"}, {Field: "id"}}
+	date := time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC)
+	values := []interface{}{date, "Muse", uint(7)}
+
+	sql, args := keysetSQL(sort, values, false)
+	wantSQL := `((release_date < ?) OR (release_date = ? AND "group" > ?) OR (release_date = ? AND "group" = ? AND id > ?))`
+	if sql != wantSQL {
+		t.Errorf("forward sql = %s, want %s", sql, wantSQL)
+	}
+	wantArgs := []interface{}{date, date, "Muse", date, "Muse", uint(7)}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("forward args = %v, want %v", args, wantArgs)
+	}
+
+	sql, _ = keysetSQL(sort, values, true)
+	wantSQL = `((release_date > ?) OR (release_date = ? AND "group" < ?) OR (release_date = ? AND "group" = ? AND id < ?))`
+	if sql != wantSQL {
+		t.Errorf("backward sql = %s, want %s", sql, wantSQL)
+	}
+}
+
+func TestMemoryGetSongsPage(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemory(zap.NewNop())
+	// у пар песен одинаковые даты: при равенстве порядок определяется id в направлении даты
+	for i := 0; i < 7; i++ {
+		song := &models.Song{
+			Group:       fmt.Sprintf("group %d", i),
+			Song:        fmt.Sprintf("song %d", i),
+			ReleaseDate: time.Date(2000+i/2, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		if _, err := repo.CreateSong(ctx, song); err != nil {
+			t.Fatalf("CreateSong: %v", err)
+		}
+	}
+	sort := []models.SortField{{Field: "release_date", Desc: true}}
+
+	var (
+		forward []uint
+		cursors []string
+		cursor  string
+	)
+	for {
+		page, err := repo.GetSongsPage(ctx, nil, sort, cursor, 3, false)
+		if err != nil {
+			t.Fatalf("GetSongsPage: %v", err)
+		}
+		for _, s := range page.Songs {
+			forward = append(forward, s.ID)
+		}
+		cursors = append(cursors, page.Prev)
+		if page.Next == "" {
+			break
+		}
+		cursor = page.Next
+	}
+	if want := []uint{7, 6, 5, 4, 3, 2, 1}; !reflect.DeepEqual(forward, want) {
+		t.Fatalf("forward order = %v, want %v", forward, want)
+	}
+	if cursors[0] != "" {
+		t.Error("first page must not have prev cursor")
+	}
+
+	page, err := repo.GetSongsPage(ctx, nil, nil, cursors[len(cursors)-1], 3, false)
+	if err != nil {
+		t.Fatalf("GetSongsPage backward: %v", err)
+	}
+	var backward []uint
+	for _, s := range page.Songs {
+		backward = append(backward, s.ID)
+	}
+	if want := []uint{4, 3, 2}; !reflect.DeepEqual(backward, want) {
+		t.Errorf("previous page = %v, want %v", backward, want)
+	}
+	if page.Next == "" || page.Prev == "" {
+		t.Errorf("middle page must have both cursors, got %+v", page)
+	}
+}
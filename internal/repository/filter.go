@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jaam8/online_song_library/internal/models"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// filterField поле, по которому разрешена фильтрация: колонка в PostgreSQL,
+// допустимые операторы и значение поля песни для хранилища в памяти
+type filterField struct {
+	column string
+	ops    []models.FilterOp
+	value  func(song *models.Song) interface{}
+}
+
+// filterFields белый список полей фильтрации; имя поля из запроса никогда не попадает в SQL
+var filterFields = map[string]filterField{
+	"group": {
+		column: `"group"`,
+		ops:    []models.FilterOp{models.FilterEq, models.FilterLike},
+		value:  func(song *models.Song) interface{} { return song.Group },
+	},
+	"song": {
+		column: "song",
+		ops:    []models.FilterOp{models.FilterEq, models.FilterLike},
+		value:  func(song *models.Song) interface{} { return song.Song },
+	},
+	"release_date": {
+		column: "release_date",
+		ops:    []models.FilterOp{models.FilterEq, models.FilterGTE, models.FilterLTE},
+		value:  func(song *models.Song) interface{} { return song.ReleaseDate },
+	},
+	"text": {
+		column: "text",
+		ops:    []models.FilterOp{models.FilterEq, models.FilterLike},
+		value:  func(song *models.Song) interface{} { return song.Text },
+	},
+	"link": {
+		column: "link",
+		ops:    []models.FilterOp{models.FilterEq, models.FilterLike},
+		value:  func(song *models.Song) interface{} { return song.Link },
+	},
+}
+
+// IsFilterField сообщает, что по полю разрешена фильтрация
+func IsFilterField(name string) bool {
+	_, ok := filterFields[name]
+	return ok
+}
+
+// lookupFilter проверяет фильтр по белому списку
+func lookupFilter(f models.SongFilter) (filterField, error) {
+	field, ok := filterFields[f.Field]
+	if !ok {
+		return filterField{}, fmt.Errorf("%w: unknown field %s", ErrInvalidFilter, f.Field)
+	}
+	if !slices.Contains(field.ops, f.Op) {
+		return filterField{}, fmt.Errorf("%w: %s does not support %s", ErrInvalidFilter, f.Field, f.Op)
+	}
+	if len(f.Values) == 0 {
+		return filterField{}, fmt.Errorf("%w: %s has no values", ErrInvalidFilter, f.Field)
+	}
+	if (f.Op == models.FilterGTE || f.Op == models.FilterLTE) && len(f.Values) > 1 {
+		return filterField{}, fmt.Errorf("%w: %s %s takes a single value", ErrInvalidFilter, f.Field, f.Op)
+	}
+	return field, nil
+}
+
+// filterSQL собирает условие WHERE для фильтра; значения передаются только параметрами
+func filterSQL(f models.SongFilter) (string, []interface{}, error) {
+	field, err := lookupFilter(f)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		sql  string
+		args []interface{}
+	)
+	switch f.Op {
+	case models.FilterEq:
+		if len(f.Values) == 1 {
+			sql, args = field.column+" = ?", f.Values
+		} else {
+			sql, args = field.column+" IN ?", []interface{}{f.Values}
+		}
+	case models.FilterLike:
+		conds := make([]string, 0, len(f.Values))
+		for _, v := range f.Values {
+			s, ok := v.(string)
+			if !ok {
+				return "", nil, fmt.Errorf("%w: %s must be a string", ErrInvalidFilter, f.Field)
+			}
+			conds = append(conds, field.column+" ILIKE ?")
+			args = append(args, "%"+escapeLike(s)+"%")
+		}
+		sql = "(" + strings.Join(conds, " OR ") + ")"
+	case models.FilterGTE:
+		sql, args = field.column+" >= ?", f.Values
+	case models.FilterLTE:
+		sql, args = field.column+" <= ?", f.Values
+	}
+	if f.Negate {
+		sql = "NOT (" + sql + ")"
+	}
+	return sql, args, nil
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы значение искалось как есть
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// matchFilters проверяет песню на соответствие всем фильтрам так же, как условия filterSQL
+func matchFilters(song *models.Song, filters []models.SongFilter) (bool, error) {
+	for _, f := range filters {
+		field, err := lookupFilter(f)
+		if err != nil {
+			return false, err
+		}
+		matched := false
+		for _, v := range f.Values {
+			if matchValue(field.value(song), f.Op, v) {
+				matched = true
+				break
+			}
+		}
+		if matched == f.Negate {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchValue(actual interface{}, op models.FilterOp, expected interface{}) bool {
+	switch a := actual.(type) {
+	case string:
+		e, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		if op == models.FilterLike {
+			return strings.Contains(strings.ToLower(a), strings.ToLower(e))
+		}
+		return a == e
+	case time.Time:
+		e, ok := expected.(time.Time)
+		if !ok {
+			return false
+		}
+		switch op {
+		case models.FilterGTE:
+			return !a.Before(e)
+		case models.FilterLTE:
+			return !a.After(e)
+		default:
+			return a.Equal(e)
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFilterSQL(t *testing.T) {
+	date := time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		filter   models.SongFilter
+		wantSQL  string
+		wantArgs []interface{}
+		wantErr  bool
+	}{
+		{
+			name:     "single value",
+			filter:   models.SongFilter{Field: "group", Op: models.FilterEq, Values: []interface{}{"Muse"}},
+			wantSQL:  `"group" = ?`,
+			wantArgs: []interface{}{"Muse"},
+		},
+		{
+			name:     "several values",
+			filter:   models.SongFilter{Field: "song", Op: models.FilterEq, Values: []interface{}{"a", "b"}},
+			wantSQL:  "song IN ?",
+			wantArgs: []interface{}{[]interface{}{"a", "b"}},
+		},
+		{
+			name:     "like escapes wildcards",
+			filter:   models.SongFilter{Field: "text", Op: models.FilterLike, Values: []interface{}{"100%", "a_b"}},
+			wantSQL:  "(text ILIKE ? OR text ILIKE ?)",
+			wantArgs: []interface{}{`%100\%%`, `%a\_b%`},
+		},
+		{
+			name:     "negated range",
+			filter:   models.SongFilter{Field: "release_date", Op: models.FilterGTE, Values: []interface{}{date}, Negate: true},
+			wantSQL:  "NOT (release_date >= ?)",
+			wantArgs: []interface{}{date},
+		},
+		{
+			name:    "unknown field",
+			filter:  models.SongFilter{Field: "album", Op: models.FilterEq, Values: []interface{}{"x"}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported operator",
+			filter:  models.SongFilter{Field: "link", Op: models.FilterGTE, Values: []interface{}{"x"}},
+			wantErr: true,
+		},
+		{
+			name:    "range with several values",
+			filter:  models.SongFilter{Field: "release_date", Op: models.FilterLTE, Values: []interface{}{date, date}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := filterSQL(tt.filter)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidFilter) {
+					t.Fatalf("expected ErrInvalidFilter, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if sql != tt.wantSQL || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("filterSQL = %q %v, want %q %v", sql, args, tt.wantSQL, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestMatchFilters(t *testing.T) {
+	song := &models.Song{
+		Group:       "Muse",
+		Song:        "Supermassive Black Hole",
+		ReleaseDate: time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name    string
+		filters []models.SongFilter
+		want    bool
+	}{
+		{
+			name:    "any of the values",
+			filters: []models.SongFilter{{Field: "group", Op: models.FilterEq, Values: []interface{}{"Queen", "Muse"}}},
+			want:    true,
+		},
+		{
+			name:    "like ignores case",
+			filters: []models.SongFilter{{Field: "song", Op: models.FilterLike, Values: []interface{}{"black HOLE"}}},
+			want:    true,
+		},
+		{
+			name:    "negation",
+			filters: []models.SongFilter{{Field: "group", Op: models.FilterEq, Values: []interface{}{"Muse"}, Negate: true}},
+			want:    false,
+		},
+		{
+			name: "date range is inclusive",
+			filters: []models.SongFilter{
+				{Field: "release_date", Op: models.FilterGTE, Values: []interface{}{song.ReleaseDate}},
+				{Field: "release_date", Op: models.FilterLTE, Values: []interface{}{song.ReleaseDate}},
+			},
+			want: true,
+		},
+		{
+			name: "all filters must match",
+			filters: []models.SongFilter{
+				{Field: "group", Op: models.FilterEq, Values: []interface{}{"Muse"}},
+				{Field: "song", Op: models.FilterLike, Values: []interface{}{"uprising"}},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchFilters(song, tt.filters)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("matchFilters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"github.com/jaam8/online_song_library/internal/models"
+	"gorm.io/gorm"
+	"slices"
+	"time"
+)
+
+func (s *MemorySongRepository) CreateRevision(ctx context.Context, revision *models.SongRevision) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.songs[revision.SongID]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	s.nextRevisionID++
+	revision.ID = s.nextRevisionID
+	revision.CreatedAt = time.Now()
+	s.revisions[revision.SongID] = append(s.revisions[revision.SongID], *revision)
+	return nil
+}
+
+func (s *MemorySongRepository) GetRevisions(ctx context.Context, songID uint, limit, offset int) ([]models.SongRevision, int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := slices.Clone(s.revisions[songID])
+	slices.Reverse(revisions)
+	totalCount := int64(len(revisions))
+
+	start := max((offset-1)*limit, 0)
+	if start >= len(revisions) {
+		return []models.SongRevision{}, totalCount, nil
+	}
+	return revisions[start:min(start+limit, len(revisions))], totalCount, nil
+}
+
+func (s *MemorySongRepository) GetRevision(ctx context.Context, songID, revisionID uint) (*models.SongRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, revision := range s.revisions[songID] {
+		if revision.ID == revisionID {
+			return &revision, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *MemorySongRepository) GetLatestRevision(ctx context.Context, songID uint) (*models.SongRevision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := s.revisions[songID]
+	if len(revisions) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	revision := revisions[len(revisions)-1]
+	return &revision, nil
+}
+
+func (s *MemorySongRepository) ReplaceSong(ctx context.Context, song *models.Song) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.songs[song.ID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	stored.Group = song.Group
+	stored.Song = song.Song
+	stored.ReleaseDate = song.ReleaseDate
+	stored.Text = song.Text
+	stored.Lyrics = song.Lyrics
+	stored.Link = song.Link
+	stored.Sources = song.Sources
+	s.songs[song.ID] = stored
+	return nil
+}
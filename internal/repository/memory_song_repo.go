@@ -0,0 +1,382 @@
+package repository
+
+import (
+	"context"
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemorySongRepository потокобезопасное хранилище песен в памяти
+// с той же фильтрацией и пагинацией, что и SongRepository
+type MemorySongRepository struct {
+	mu             sync.RWMutex
+	songs          map[uint]models.Song
+	translations   map[uint]map[string]models.SongTranslation
+	revisions      map[uint][]models.SongRevision
+	nextID         uint
+	nextRevisionID uint
+	l              *zap.Logger
+}
+
+func NewMemory(log *zap.Logger) *MemorySongRepository {
+	return &MemorySongRepository{
+		songs:        make(map[uint]models.Song),
+		translations: make(map[uint]map[string]models.SongTranslation),
+		revisions:    make(map[uint][]models.SongRevision),
+		nextID:       1,
+		l:            log,
+	}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет — логгер репозитория
+func (s *MemorySongRepository) log(ctx context.Context) *zap.Logger {
+	return logger.FromContextOr(ctx, s.l)
+}
+
+func (s *MemorySongRepository) CreateSong(ctx context.Context, song *models.Song) (uint, error) {
+	s.log(ctx).Debug("starting create song", zap.Any("song", song))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	song.ID = s.nextID
+	s.nextID++
+	s.songs[song.ID] = *song
+	s.log(ctx).Debug("song created", zap.Uint("id", song.ID))
+	return song.ID, nil
+}
+
+func (s *MemorySongRepository) GetAllSongs(ctx context.Context, limit, offset int, filters []models.SongFilter, sort []models.SortField) ([]models.Song, int64, error) {
+	s.log(ctx).Debug("starting get all songs",
+		zap.Any("filters", filters),
+		zap.Any("sort", sort),
+		zap.Int("limit", limit),
+		zap.Int("offset", offset))
+	sort, err := normalizeSort(sort)
+	if err != nil {
+		s.log(ctx).Debug("invalid sort", zap.Error(err))
+		return nil, 0, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := make([]models.Song, 0)
+	for _, song := range s.sortedSongs() {
+		ok, err := matchFilters(&song, filters)
+		if err != nil {
+			s.log(ctx).Debug("invalid filter", zap.Error(err))
+			return nil, 0, err
+		}
+		if ok {
+			matched = append(matched, song)
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b models.Song) int { return compareSongs(&a, &b, sort) })
+	totalCount := int64(len(matched))
+
+	start := (offset - 1) * limit
+	if start < 0 {
+		start = 0
+	}
+	if start >= len(matched) {
+		return []models.Song{}, totalCount, nil
+	}
+	end := min(start+limit, len(matched))
+	s.log(ctx).Debug("retrieved songs",
+		zap.Int("count", end-start),
+		zap.Int64("total", totalCount))
+	return matched[start:end], totalCount, nil
+}
+
+func (s *MemorySongRepository) GetSongsPage(ctx context.Context, filters []models.SongFilter, sort []models.SortField, cursor string, limit int, withTotal bool) (*models.SongPage, error) {
+	s.log(ctx).Debug("starting get songs page",
+		zap.Any("filters", filters),
+		zap.Any("sort", sort),
+		zap.Bool("cursor", cursor != ""),
+		zap.Int("limit", limit))
+	sort, key, backward, err := resolveCursor(sort, cursor)
+	if err != nil {
+		s.log(ctx).Debug("invalid cursor or sort", zap.Error(err))
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var totalCount int64
+	matched := make([]models.Song, 0)
+	for _, song := range s.sortedSongs() {
+		ok, err := matchFilters(&song, filters)
+		if err != nil {
+			s.log(ctx).Debug("invalid filter", zap.Error(err))
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		totalCount++
+		if key == nil || afterKey(&song, sort, key, backward) {
+			matched = append(matched, song)
+		}
+	}
+	slices.SortStableFunc(matched, func(a, b models.Song) int {
+		if backward {
+			return compareSongs(&b, &a, sort)
+		}
+		return compareSongs(&a, &b, sort)
+	})
+
+	page := buildPage(matched[:min(limit+1, len(matched))], sort, limit, key != nil, backward)
+	if withTotal {
+		page.Total = &totalCount
+	}
+	s.log(ctx).Debug("retrieved songs page",
+		zap.Int("count", len(page.Songs)),
+		zap.Bool("has_next", page.Next != ""),
+		zap.Bool("has_prev", page.Prev != ""))
+	return page, nil
+}
+
+func (s *MemorySongRepository) CountSongs(ctx context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.songs)), nil
+}
+
+// SearchSongs ищет песни по словам названия и текста без учета морфологии, как конфигурация simple;
+// language не используется
+func (s *MemorySongRepository) SearchSongs(ctx context.Context, query, language string, limit, offset int) ([]models.SongSearchHit, int64, error) {
+	s.log(ctx).Debug("starting search songs",
+		zap.String("query", query),
+		zap.Int("limit", limit),
+		zap.Int("offset", offset))
+	q := parseSearchQuery(query)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits := make([]models.SongSearchHit, 0)
+	for _, song := range s.sortedSongs() {
+		rank, ok := q.match(splitWords(song.Group+" "+song.Song), splitWords(song.Text))
+		if !ok {
+			continue
+		}
+		hits = append(hits, models.SongSearchHit{
+			Song:       song,
+			Rank:       rank,
+			Highlights: highlightedVerses(q.highlight(song.Text)),
+		})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Rank > hits[j].Rank })
+	totalCount := int64(len(hits))
+
+	start := max((offset-1)*limit, 0)
+	if start >= len(hits) {
+		return []models.SongSearchHit{}, totalCount, nil
+	}
+	end := min(start+limit, len(hits))
+	s.log(ctx).Debug("found songs",
+		zap.Int("count", end-start),
+		zap.Int64("total", totalCount))
+	return hits[start:end], totalCount, nil
+}
+
+func (s *MemorySongRepository) GetSong(ctx context.Context, id uint) (*models.Song, error) {
+	s.log(ctx).Debug("starting get song", zap.Uint("id", id))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	song, ok := s.songs[id]
+	if !ok {
+		s.log(ctx).Warn("no song found", zap.Uint("id", id))
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &song, nil
+}
+
+// UpdateSong как и gorm Updates со структурой, обновляет только непустые поля
+func (s *MemorySongRepository) UpdateSong(ctx context.Context, id uint, updatedSong models.Song) error {
+	s.log(ctx).Debug("starting update song",
+		zap.Uint("id", id),
+		zap.Any("update data", updatedSong))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	song, ok := s.songs[id]
+	if !ok {
+		s.log(ctx).Warn("no song updated", zap.Uint("id", id))
+		return gorm.ErrRecordNotFound
+	}
+	if updatedSong.Group != "" {
+		song.Group = updatedSong.Group
+	}
+	if updatedSong.Song != "" {
+		song.Song = updatedSong.Song
+	}
+	if !updatedSong.ReleaseDate.IsZero() {
+		song.ReleaseDate = updatedSong.ReleaseDate
+	}
+	if updatedSong.Text != "" {
+		song.Text = updatedSong.Text
+	}
+	if updatedSong.Lyrics != nil {
+		song.Lyrics = updatedSong.Lyrics
+	}
+	if updatedSong.Link != "" {
+		song.Link = updatedSong.Link
+	}
+	if updatedSong.Sources.ReleaseDate != "" {
+		song.Sources.ReleaseDate = updatedSong.Sources.ReleaseDate
+	}
+	if updatedSong.Sources.Text != "" {
+		song.Sources.Text = updatedSong.Sources.Text
+	}
+	if updatedSong.Sources.Link != "" {
+		song.Sources.Link = updatedSong.Sources.Link
+	}
+	s.songs[id] = song
+	s.log(ctx).Debug("song updated successfully", zap.Uint("id", id))
+	return nil
+}
+
+func (s *MemorySongRepository) DeleteSong(ctx context.Context, id uint) error {
+	s.log(ctx).Debug("starting delete song", zap.Uint("id", id))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.songs[id]; !ok {
+		s.log(ctx).Warn("no song deleted", zap.Uint("id", id))
+		return gorm.ErrRecordNotFound
+	}
+	delete(s.songs, id)
+	delete(s.translations, id)
+	delete(s.revisions, id)
+	s.log(ctx).Debug("song deleted successfully", zap.Uint("id", id))
+	return nil
+}
+
+func (s *MemorySongRepository) GetSongIDsByEnrichmentStatus(ctx context.Context, status models.EnrichmentStatus) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []uint
+	for _, song := range s.sortedSongs() {
+		if song.EnrichmentStatus == status {
+			ids = append(ids, song.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (s *MemorySongRepository) UpdateEnrichment(ctx context.Context, old, song *models.Song) error {
+	s.log(ctx).Debug("starting update enrichment",
+		zap.Uint("id", song.ID),
+		zap.String("status", string(song.EnrichmentStatus)))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.songs[song.ID]
+	if !ok {
+		s.log(ctx).Warn("no song updated", zap.Uint("id", song.ID))
+		return gorm.ErrRecordNotFound
+	}
+	if !sameEnrichmentState(&stored, old) {
+		s.log(ctx).Warn("song modified concurrently, enrichment not saved", zap.Uint("id", song.ID))
+		return ErrSongModified
+	}
+	stored.ReleaseDate = song.ReleaseDate
+	stored.Text = song.Text
+	stored.Lyrics = song.Lyrics
+	stored.Link = song.Link
+	stored.Sources = song.Sources
+	stored.EnrichmentStatus = song.EnrichmentStatus
+	stored.EnrichmentAttempts = song.EnrichmentAttempts
+	stored.EnrichmentError = song.EnrichmentError
+	stored.EnrichedAt = song.EnrichedAt
+	s.songs[song.ID] = stored
+	return nil
+}
+
+// sameEnrichmentState сравнивает поля, по которым SongRepository.UpdateEnrichment проверяет, что песня не менялась
+func sameEnrichmentState(a, b *models.Song) bool {
+	return a.Group == b.Group && a.Song == b.Song &&
+		a.ReleaseDate.Equal(b.ReleaseDate) && a.Text == b.Text && a.Link == b.Link &&
+		a.Sources == b.Sources &&
+		a.EnrichmentStatus == b.EnrichmentStatus && a.EnrichmentAttempts == b.EnrichmentAttempts
+}
+
+func (s *MemorySongRepository) GetSongIDsWithoutLyrics(ctx context.Context) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []uint
+	for _, song := range s.sortedSongs() {
+		if song.Lyrics == nil {
+			ids = append(ids, song.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (s *MemorySongRepository) UpdateLyrics(ctx context.Context, id uint, structured *lyrics.Structured) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	song, ok := s.songs[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	song.Lyrics = structured
+	s.songs[id] = song
+	return nil
+}
+
+func (s *MemorySongRepository) UpdateSyncedLyrics(ctx context.Context, id uint, synced *lyrics.Synced) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	song, ok := s.songs[id]
+	if !ok {
+		s.log(ctx).Warn("no song updated", zap.Uint("id", id))
+		return gorm.ErrRecordNotFound
+	}
+	song.SyncedLyrics = synced
+	s.songs[id] = song
+	return nil
+}
+
+func (s *MemorySongRepository) GetStaleSongIDs(ctx context.Context, before time.Time) ([]uint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []uint
+	for _, song := range s.sortedSongs() {
+		if song.EnrichmentStatus != models.EnrichmentEnriched {
+			continue
+		}
+		if song.EnrichedAt != nil && !song.EnrichedAt.Before(before) {
+			continue
+		}
+		if song.Sources.ReleaseDate == models.SourceManual &&
+			song.Sources.Text == models.SourceManual &&
+			song.Sources.Link == models.SourceManual {
+			continue
+		}
+		ids = append(ids, song.ID)
+	}
+	return ids, nil
+}
+
+// sortedSongs возвращает копии песен в порядке ID; вызывается под блокировкой
+func (s *MemorySongRepository) sortedSongs() []models.Song {
+	songs := make([]models.Song, 0, len(s.songs))
+	for _, song := range s.songs {
+		songs = append(songs, song)
+	}
+	sort.Slice(songs, func(i, j int) bool { return songs[i].ID < songs[j].ID })
+	return songs
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"github.com/jaam8/online_song_library/internal/models"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (s *MemorySongRepository) GetTranslations(ctx context.Context, songID uint) ([]models.SongTranslation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	translations := make([]models.SongTranslation, 0, len(s.translations[songID]))
+	for _, t := range s.translations[songID] {
+		translations = append(translations, t)
+	}
+	slices.SortFunc(translations, func(a, b models.SongTranslation) int {
+		return strings.Compare(a.Language, b.Language)
+	})
+	return translations, nil
+}
+
+func (s *MemorySongRepository) GetTranslation(ctx context.Context, songID uint, language string) (*models.SongTranslation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.translations[songID][language]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &t, nil
+}
+
+// SaveTranslation как и внешний ключ в PostgreSQL, не сохраняет перевод несуществующей песни
+func (s *MemorySongRepository) SaveTranslation(ctx context.Context, translation *models.SongTranslation) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.songs[translation.SongID]; !ok {
+		return false, gorm.ErrRecordNotFound
+	}
+	if s.translations[translation.SongID] == nil {
+		s.translations[translation.SongID] = make(map[string]models.SongTranslation)
+	}
+	now := time.Now()
+	stored, exists := s.translations[translation.SongID][translation.Language]
+	if !exists {
+		stored = models.SongTranslation{SongID: translation.SongID, Language: translation.Language, CreatedAt: now}
+	}
+	stored.Text = translation.Text
+	stored.UpdatedAt = now
+	s.translations[translation.SongID][translation.Language] = stored
+	*translation = stored
+	return !exists, nil
+}
+
+func (s *MemorySongRepository) DeleteTranslation(ctx context.Context, songID uint, language string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.translations[songID][language]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(s.translations[songID], language)
+	return nil
+}
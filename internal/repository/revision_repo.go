@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"github.com/jaam8/online_song_library/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// RevisionStore история изменений песен и восстановление песни из ревизии
+type RevisionStore interface {
+	CreateRevision(ctx context.Context, revision *models.SongRevision) error
+	GetRevisions(ctx context.Context, songID uint, limit, offset int) ([]models.SongRevision, int64, error)
+	GetRevision(ctx context.Context, songID, revisionID uint) (*models.SongRevision, error)
+	GetLatestRevision(ctx context.Context, songID uint) (*models.SongRevision, error)
+	ReplaceSong(ctx context.Context, song *models.Song) error
+}
+
+var (
+	_ RevisionStore = (*SongRepository)(nil)
+	_ RevisionStore = (*MemorySongRepository)(nil)
+)
+
+func (s *SongRepository) CreateRevision(ctx context.Context, revision *models.SongRevision) error {
+	s.log(ctx).Debug("starting create revision",
+		zap.Uint("song_id", revision.SongID),
+		zap.String("author", revision.Author))
+	if err := s.conn(ctx, "CreateRevision").Create(revision).Error; err != nil {
+		s.log(ctx).Error("failed to create revision",
+			zap.Uint("song_id", revision.SongID),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// GetRevisions возвращает ревизии песни от новых к старым
+func (s *SongRepository) GetRevisions(ctx context.Context, songID uint, limit, offset int) ([]models.SongRevision, int64, error) {
+	var (
+		revisions  []models.SongRevision
+		totalCount int64
+	)
+	baseQuery := s.conn(ctx, "GetRevisions").Model(&models.SongRevision{}).Where("song_id = ?", songID)
+	if err := baseQuery.Count(&totalCount).Error; err != nil {
+		s.log(ctx).Error("failed to count revisions", zap.Error(err))
+		return nil, 0, err
+	}
+	err := baseQuery.Order("id DESC").Limit(limit).Offset((offset - 1) * limit).Find(&revisions).Error
+	if err != nil {
+		s.log(ctx).Error("failed to get revisions", zap.Error(err))
+		return nil, 0, err
+	}
+	return revisions, totalCount, nil
+}
+
+// GetRevision возвращает ревизию песни; ревизия другой песни считается ненайденной
+func (s *SongRepository) GetRevision(ctx context.Context, songID, revisionID uint) (*models.SongRevision, error) {
+	var revision models.SongRevision
+	result := s.conn(ctx, "GetRevision").
+		Where("song_id = ? AND id = ?", songID, revisionID).
+		Limit(1).
+		Find(&revision)
+	if result.Error != nil {
+		s.log(ctx).Error("failed to get revision",
+			zap.Uint("song_id", songID),
+			zap.Uint("revision", revisionID),
+			zap.Error(result.Error))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &revision, nil
+}
+
+// GetLatestRevision возвращает последнюю ревизию песни, а если ревизий нет — gorm.ErrRecordNotFound
+func (s *SongRepository) GetLatestRevision(ctx context.Context, songID uint) (*models.SongRevision, error) {
+	var revision models.SongRevision
+	result := s.conn(ctx, "GetLatestRevision").
+		Where("song_id = ?", songID).
+		Order("id DESC").
+		Limit(1).
+		Find(&revision)
+	if result.Error != nil {
+		s.log(ctx).Error("failed to get latest revision",
+			zap.Uint("song_id", songID),
+			zap.Error(result.Error))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &revision, nil
+}
+
+// ReplaceSong сохраняет все редактируемые поля песни вместе с их источниками, включая пустые значения
+func (s *SongRepository) ReplaceSong(ctx context.Context, song *models.Song) error {
+	s.log(ctx).Debug("starting replace song", zap.Uint("id", song.ID))
+	result := s.conn(ctx, "ReplaceSong").Model(&models.Song{ID: song.ID}).
+		Select("group", "song", "release_date", "text", "lyrics", "link",
+			"release_date_source", "text_source", "link_source").
+		Updates(song)
+	if result.Error != nil {
+		s.log(ctx).Error("failed to replace song",
+			zap.Uint("id", song.ID),
+			zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		s.log(ctx).Warn("no song updated", zap.Uint("id", song.ID))
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
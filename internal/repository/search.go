@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// SearchLanguages конфигурации полнотекстового поиска PostgreSQL, для которых строится search_vector
+var SearchLanguages = []string{"simple", "english", "russian"}
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+	maxHighlights  = 3
+)
+
+// highlightedVerses возвращает строки текста, в которых есть выделенные совпадения
+func highlightedVerses(text string) []string {
+	verses := make([]string, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.Contains(line, highlightStart) {
+			verses = append(verses, strings.TrimSpace(line))
+			if len(verses) == maxHighlights {
+				break
+			}
+		}
+	}
+	return verses
+}
+
+// wordSpan слово текста и его границы в байтах
+type wordSpan struct {
+	word       string
+	start, end int
+}
+
+// splitWords разбивает текст на слова из букв и цифр в нижнем регистре
+func splitWords(text string) []wordSpan {
+	var (
+		words []wordSpan
+		start = -1
+	)
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			words = append(words, wordSpan{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, wordSpan{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return words
+}
+
+// searchTerm слово или фраза запроса
+type searchTerm struct {
+	words  []string
+	negate bool
+}
+
+// searchQuery запрос в синтаксисе websearch_to_tsquery: термы одного клауза объединяются через or,
+// клаузы — через and
+type searchQuery [][]searchTerm
+
+// parseSearchQuery разбирает запрос в синтаксисе websearch_to_tsquery: слова, "фразы в кавычках",
+// -исключение и or между соседними термами
+func parseSearchQuery(q string) searchQuery {
+	var (
+		query  searchQuery
+		pendOr bool
+	)
+	add := func(text string, negate bool) {
+		words := make([]string, 0)
+		for _, w := range splitWords(text) {
+			words = append(words, w.word)
+		}
+		if len(words) == 0 {
+			return
+		}
+		term := searchTerm{words: words, negate: negate}
+		if pendOr && len(query) > 0 {
+			query[len(query)-1] = append(query[len(query)-1], term)
+		} else {
+			query = append(query, []searchTerm{term})
+		}
+		pendOr = false
+	}
+
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		negate := false
+		if q[0] == '-' {
+			negate = true
+			q = q[1:]
+		}
+		if strings.HasPrefix(q, `"`) {
+			end := strings.Index(q[1:], `"`)
+			if end < 0 {
+				add(q[1:], negate)
+				break
+			}
+			add(q[1:end+1], negate)
+			q = q[end+2:]
+			continue
+		}
+		token, rest, _ := strings.Cut(q, " ")
+		q = rest
+		if !negate && strings.EqualFold(token, "or") {
+			pendOr = len(query) > 0
+			continue
+		}
+		add(token, negate)
+	}
+	return query
+}
+
+// countPhrase считает вхождения фразы подряд идущими словами
+func countPhrase(words []wordSpan, phrase []string) int {
+	count := 0
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		matched := true
+		for j, w := range phrase {
+			if words[i+j].word != w {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			count++
+		}
+	}
+	return count
+}
+
+// match проверяет песню на соответствие запросу и возвращает ранг: совпадения в названии
+// весят больше совпадений в тексте, как веса A и B в search_vector
+func (q searchQuery) match(title, text []wordSpan) (float64, bool) {
+	if len(q) == 0 {
+		return 0, false
+	}
+	var rank float64
+	for _, clause := range q {
+		clauseMatched := false
+		for _, term := range clause {
+			inTitle, inText := countPhrase(title, term.words), countPhrase(text, term.words)
+			found := inTitle+inText > 0
+			if term.negate {
+				found = !found
+			} else {
+				rank += float64(inTitle) + 0.4*float64(inText)
+			}
+			clauseMatched = clauseMatched || found
+		}
+		if !clauseMatched {
+			return 0, false
+		}
+	}
+	return rank, true
+}
+
+// highlight выделяет в тексте слова из положительных термов запроса
+func (q searchQuery) highlight(text string) string {
+	var words []string
+	for _, clause := range q {
+		for _, term := range clause {
+			if !term.negate {
+				words = append(words, term.words...)
+			}
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, w := range splitWords(text) {
+		if !slices.Contains(words, w.word) {
+			continue
+		}
+		b.WriteString(text[last:w.start])
+		b.WriteString(highlightStart)
+		b.WriteString(text[w.start:w.end])
+		b.WriteString(highlightStop)
+		last = w.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
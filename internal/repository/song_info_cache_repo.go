@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"github.com/jaam8/online_song_library/internal/metrics"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// songInfoCacheRow строка таблицы song_info_cache
+type songInfoCacheRow struct {
+	Group       string `gorm:"primaryKey"`
+	Song        string `gorm:"primaryKey"`
+	ReleaseDate *time.Time
+	Text        *string
+	Link        *string
+	NotFound    bool
+	ExpiresAt   time.Time
+	Sources     models.SongSources `gorm:"embedded"`
+}
+
+func (songInfoCacheRow) TableName() string {
+	return "song_info_cache"
+}
+
+// SongInfoCacheRepository кеш ответов внешнего API в таблице postgres
+type SongInfoCacheRepository struct {
+	db *gorm.DB
+	l  *zap.Logger
+}
+
+func NewSongInfoCache(db *gorm.DB, log *zap.Logger) *SongInfoCacheRepository {
+	return &SongInfoCacheRepository{db: db, l: log}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет — логгер репозитория
+func (r *SongInfoCacheRepository) log(ctx context.Context) *zap.Logger {
+	return logger.FromContextOr(ctx, r.l)
+}
+
+func (r *SongInfoCacheRepository) conn(ctx context.Context, method string) *gorm.DB {
+	return r.db.WithContext(metrics.WithDBMethod(ctx, method))
+}
+
+func (r *SongInfoCacheRepository) Get(ctx context.Context, group, song string) (*models.SongInfoCacheEntry, bool, error) {
+	var row songInfoCacheRow
+	result := r.conn(ctx, "SongInfoCache.Get").
+		Where(`"group" = ? AND song = ? AND expires_at > ?`, group, song, time.Now()).
+		Limit(1).
+		Find(&row)
+	if result.Error != nil {
+		r.log(ctx).Error("failed to get song info cache entry", zap.Error(result.Error))
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, nil
+	}
+
+	entry := &models.SongInfoCacheEntry{
+		Group:     row.Group,
+		Song:      row.Song,
+		ExpiresAt: row.ExpiresAt,
+	}
+	if !row.NotFound {
+		entry.Info = &models.SongInfo{Sources: row.Sources}
+		if row.ReleaseDate != nil {
+			entry.Info.ReleaseDate = *row.ReleaseDate
+		}
+		if row.Text != nil {
+			entry.Info.Text = *row.Text
+		}
+		if row.Link != nil {
+			entry.Info.Link = *row.Link
+		}
+	}
+	return entry, true, nil
+}
+
+func (r *SongInfoCacheRepository) Set(ctx context.Context, entry *models.SongInfoCacheEntry) error {
+	row := songInfoCacheRow{
+		Group:     entry.Group,
+		Song:      entry.Song,
+		NotFound:  entry.Info == nil,
+		ExpiresAt: entry.ExpiresAt,
+	}
+	if entry.Info != nil {
+		row.ReleaseDate = &entry.Info.ReleaseDate
+		row.Text = &entry.Info.Text
+		row.Link = &entry.Info.Link
+		row.Sources = entry.Info.Sources
+	}
+	err := r.conn(ctx, "SongInfoCache.Set").Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error
+	if err != nil {
+		r.log(ctx).Error("failed to set song info cache entry", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *SongInfoCacheRepository) Delete(ctx context.Context, group, song string) (int64, error) {
+	result := r.conn(ctx, "SongInfoCache.Delete").Where(`"group" = ? AND song = ?`, group, song).Delete(&songInfoCacheRow{})
+	if result.Error != nil {
+		r.log(ctx).Error("failed to delete song info cache entry", zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *SongInfoCacheRepository) Purge(ctx context.Context) (int64, error) {
+	result := r.conn(ctx, "SongInfoCache.Purge").Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&songInfoCacheRow{})
+	if result.Error != nil {
+		r.log(ctx).Error("failed to purge song info cache", zap.Error(result.Error))
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
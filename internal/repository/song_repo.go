@@ -1,11 +1,20 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"github.com/jaam8/online_song_library/internal/metrics"
 	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/pkg/logger"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"time"
 )
 
+// ErrSongModified песня изменилась после чтения, и условное обновление не применено
+var ErrSongModified = errors.New("song was modified concurrently")
+
 type SongRepository struct {
 	db *gorm.DB
 	l  *zap.Logger
@@ -15,102 +24,372 @@ func New(db *gorm.DB, log *zap.Logger) *SongRepository {
 	return &SongRepository{db: db, l: log}
 }
 
-func (s *SongRepository) CreateSong(song *models.Song) (uint, error) {
-	s.l.Debug("starting create song", zap.Any("song", song))
-	err := s.db.Create(&song).Error
+// log возвращает логгер запроса из ctx, а если его нет — логгер репозитория
+func (s *SongRepository) log(ctx context.Context) *zap.Logger {
+	return logger.FromContextOr(ctx, s.l)
+}
+
+// conn возвращает сессию gorm, запросы которой попадают в метрики под именем метода репозитория
+func (s *SongRepository) conn(ctx context.Context, method string) *gorm.DB {
+	return s.db.WithContext(metrics.WithDBMethod(ctx, method))
+}
+
+func (s *SongRepository) CreateSong(ctx context.Context, song *models.Song) (uint, error) {
+	s.log(ctx).Debug("starting create song", zap.Any("song", song))
+	err := s.conn(ctx, "CreateSong").Create(&song).Error
 	if err != nil {
-		s.l.Error("create song failed", zap.Error(err))
+		s.log(ctx).Error("create song failed", zap.Error(err))
 		return 0, err
 	}
-	s.l.Debug("song created", zap.Uint("id", song.ID))
+	s.log(ctx).Debug("song created", zap.Uint("id", song.ID))
 	return song.ID, nil
 }
 
-func (s *SongRepository) GetAllSongs(limit, offset int, filters map[string]interface{}) ([]models.Song, int64, error) {
-	s.l.Debug("starting get all songs",
+func (s *SongRepository) GetAllSongs(ctx context.Context, limit, offset int, filters []models.SongFilter, sort []models.SortField) ([]models.Song, int64, error) {
+	s.log(ctx).Debug("starting get all songs",
 		zap.Any("filters", filters),
+		zap.Any("sort", sort),
 		zap.Int("limit", limit),
 		zap.Int("offset", offset))
 	var songs []models.Song
 	var totalCount int64
 
-	baseQuery := s.db.Model(&models.Song{})
-	for key, value := range filters {
-		if key == "group" {
-			baseQuery = baseQuery.Where(`"group" = ?`, value)
-		} else {
-			baseQuery = baseQuery.Where(key+" = ?", value)
+	sort, err := normalizeSort(sort)
+	if err != nil {
+		s.log(ctx).Debug("invalid sort", zap.Error(err))
+		return nil, 0, err
+	}
+
+	baseQuery := s.conn(ctx, "GetAllSongs").Model(&models.Song{})
+	for _, f := range filters {
+		sql, args, err := filterSQL(f)
+		if err != nil {
+			s.log(ctx).Debug("invalid filter", zap.Error(err))
+			return nil, 0, err
 		}
+		baseQuery = baseQuery.Where(sql, args...)
 	}
 
 	if err := baseQuery.Count(&totalCount).Error; err != nil {
-		s.l.Error("failed to count songs", zap.Error(err))
+		s.log(ctx).Error("failed to count songs", zap.Error(err))
 		return nil, 0, err
 	}
 
-	query := baseQuery.Limit(limit).Offset((offset - 1) * limit)
+	query := baseQuery.Order(orderSQL(sort, false)).Limit(limit).Offset((offset - 1) * limit)
 	if err := query.Find(&songs).Error; err != nil {
-		s.l.Error("failed to get songs", zap.Error(err))
+		s.log(ctx).Error("failed to get songs", zap.Error(err))
 		return nil, 0, err
 	}
-	s.l.Debug("retrieved songs",
+	s.log(ctx).Debug("retrieved songs",
 		zap.Int("count", len(songs)),
 		zap.Int64("total", totalCount))
 	return songs, totalCount, nil
 }
 
-func (s *SongRepository) GetSong(id uint) (*models.Song, error) {
-	s.l.Debug("starting get song", zap.Uint("id", id))
+// GetSongsPage читает страницу песен после (или до) курсора по ключу сортировки без OFFSET;
+// общее число песен считается только при withTotal
+func (s *SongRepository) GetSongsPage(ctx context.Context, filters []models.SongFilter, sort []models.SortField, cursor string, limit int, withTotal bool) (*models.SongPage, error) {
+	s.log(ctx).Debug("starting get songs page",
+		zap.Any("filters", filters),
+		zap.Any("sort", sort),
+		zap.Bool("cursor", cursor != ""),
+		zap.Int("limit", limit))
+	sort, key, backward, err := resolveCursor(sort, cursor)
+	if err != nil {
+		s.log(ctx).Debug("invalid cursor or sort", zap.Error(err))
+		return nil, err
+	}
+
+	baseQuery := s.conn(ctx, "GetSongsPage").Model(&models.Song{})
+	for _, f := range filters {
+		sql, args, err := filterSQL(f)
+		if err != nil {
+			s.log(ctx).Debug("invalid filter", zap.Error(err))
+			return nil, err
+		}
+		baseQuery = baseQuery.Where(sql, args...)
+	}
+
+	var totalCount *int64
+	if withTotal {
+		var count int64
+		if err := baseQuery.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+			s.log(ctx).Error("failed to count songs", zap.Error(err))
+			return nil, err
+		}
+		totalCount = &count
+	}
+
+	query := baseQuery
+	if key != nil {
+		sql, args := keysetSQL(sort, key, backward)
+		query = query.Where(sql, args...)
+	}
+	var songs []models.Song
+	if err := query.Order(orderSQL(sort, backward)).Limit(limit + 1).Find(&songs).Error; err != nil {
+		s.log(ctx).Error("failed to get songs", zap.Error(err))
+		return nil, err
+	}
+
+	page := buildPage(songs, sort, limit, key != nil, backward)
+	page.Total = totalCount
+	s.log(ctx).Debug("retrieved songs page",
+		zap.Int("count", len(page.Songs)),
+		zap.Bool("has_next", page.Next != ""),
+		zap.Bool("has_prev", page.Prev != ""))
+	return page, nil
+}
+
+func (s *SongRepository) CountSongs(ctx context.Context) (int64, error) {
+	var count int64
+	if err := s.conn(ctx, "CountSongs").Model(&models.Song{}).Count(&count).Error; err != nil {
+		s.log(ctx).Error("failed to count songs", zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
+
+// songSearchRow строка результата полнотекстового поиска
+type songSearchRow struct {
+	models.Song `gorm:"embedded"`
+	Rank        float64
+	Headline    string
+}
+
+// SearchSongs ищет песни по search_vector запросом в синтаксисе websearch_to_tsquery
+// и сортирует их по рангу; language — конфигурация из SearchLanguages
+func (s *SongRepository) SearchSongs(ctx context.Context, query, language string, limit, offset int) ([]models.SongSearchHit, int64, error) {
+	s.log(ctx).Debug("starting search songs",
+		zap.String("query", query),
+		zap.String("language", language),
+		zap.Int("limit", limit),
+		zap.Int("offset", offset))
+	var totalCount int64
+	err := s.conn(ctx, "SearchSongs").Model(&models.Song{}).
+		Where("search_vector @@ websearch_to_tsquery(?::regconfig, ?)", language, query).
+		Count(&totalCount).Error
+	if err != nil {
+		s.log(ctx).Error("failed to count found songs", zap.Error(err))
+		return nil, 0, err
+	}
+
+	var rows []songSearchRow
+	err = s.conn(ctx, "SearchSongs").Raw(`
+		SELECT songs.*,
+			ts_rank(search_vector, q) AS rank,
+			ts_headline(?::regconfig, text, q, ?) AS headline
+		FROM songs, websearch_to_tsquery(?::regconfig, ?) AS q
+		WHERE search_vector @@ q
+		ORDER BY rank DESC, id
+		LIMIT ? OFFSET ?`,
+		language, "HighlightAll=true, StartSel="+highlightStart+", StopSel="+highlightStop,
+		language, query,
+		limit, (offset-1)*limit).
+		Scan(&rows).Error
+	if err != nil {
+		s.log(ctx).Error("failed to search songs", zap.Error(err))
+		return nil, 0, err
+	}
+
+	hits := make([]models.SongSearchHit, 0, len(rows))
+	for _, row := range rows {
+		hits = append(hits, models.SongSearchHit{
+			Song:       row.Song,
+			Rank:       row.Rank,
+			Highlights: highlightedVerses(row.Headline),
+		})
+	}
+	s.log(ctx).Debug("found songs",
+		zap.Int("count", len(hits)),
+		zap.Int64("total", totalCount))
+	return hits, totalCount, nil
+}
+
+func (s *SongRepository) GetSong(ctx context.Context, id uint) (*models.Song, error) {
+	s.log(ctx).Debug("starting get song", zap.Uint("id", id))
 	var song models.Song
-	result := s.db.First(&song, id)
+	result := s.conn(ctx, "GetSong").First(&song, id)
 	if result.Error != nil {
-		s.l.Error("failed to get song",
+		s.log(ctx).Error("failed to get song",
 			zap.Uint("id", id),
 			zap.Error(result.Error))
 		return nil, result.Error
 	}
 	if result.RowsAffected == 0 {
-		s.l.Warn("no song found", zap.Uint("id", id))
+		s.log(ctx).Warn("no song found", zap.Uint("id", id))
 		return nil, gorm.ErrRecordNotFound
 	}
-	s.l.Debug("song retrieved", zap.Uint("id", song.ID))
+	s.log(ctx).Debug("song retrieved", zap.Uint("id", song.ID))
 	return &song, nil
 }
 
-func (s *SongRepository) UpdateSong(id uint, updatedSong models.Song) error {
-	s.l.Debug("starting update song",
+func (s *SongRepository) UpdateSong(ctx context.Context, id uint, updatedSong models.Song) error {
+	s.log(ctx).Debug("starting update song",
 		zap.Uint("id", id),
 		zap.Any("update data", updatedSong))
-	result := s.db.Where("id = ?", id).Updates(updatedSong)
+	result := s.conn(ctx, "UpdateSong").Where("id = ?", id).Updates(updatedSong)
 	if result.Error != nil {
-		s.l.Error("failed to update song",
+		s.log(ctx).Error("failed to update song",
 			zap.Uint("id", id),
 			zap.Error(result.Error))
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		s.l.Warn("no song updated", zap.Uint("id", id))
+		s.log(ctx).Warn("no song updated", zap.Uint("id", id))
 		return gorm.ErrRecordNotFound
 	}
-	s.l.Debug("song updated successfully",
+	s.log(ctx).Debug("song updated successfully",
 		zap.Uint("id", id),
 		zap.Int64("rowsAffected", result.RowsAffected))
 	return nil
 }
 
-func (s *SongRepository) DeleteSong(id uint) error {
-	s.l.Debug("starting delete song", zap.Uint("id", id))
-	result := s.db.Where("id = ?", id).Delete(&models.Song{})
+func (s *SongRepository) DeleteSong(ctx context.Context, id uint) error {
+	s.log(ctx).Debug("starting delete song", zap.Uint("id", id))
+	result := s.conn(ctx, "DeleteSong").Where("id = ?", id).Delete(&models.Song{})
 	if result.Error != nil {
-		s.l.Error("failed to delete song",
+		s.log(ctx).Error("failed to delete song",
 			zap.Uint("id", id),
 			zap.Error(result.Error))
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		s.l.Warn("no song deleted", zap.Uint("id", id))
+		s.log(ctx).Warn("no song deleted", zap.Uint("id", id))
 		return gorm.ErrRecordNotFound
 	}
-	s.l.Debug("song deleted successfully", zap.Uint("id", id))
+	s.log(ctx).Debug("song deleted successfully", zap.Uint("id", id))
 	return nil
 }
+
+func (s *SongRepository) GetSongIDsByEnrichmentStatus(ctx context.Context, status models.EnrichmentStatus) ([]uint, error) {
+	s.log(ctx).Debug("starting get song ids by enrichment status", zap.String("status", string(status)))
+	var ids []uint
+	err := s.conn(ctx, "GetSongIDsByEnrichmentStatus").Model(&models.Song{}).
+		Where("enrichment_status = ?", status).
+		Order("id").
+		Pluck("id", &ids).Error
+	if err != nil {
+		s.log(ctx).Error("failed to get song ids by enrichment status",
+			zap.String("status", string(status)),
+			zap.Error(err))
+		return nil, err
+	}
+	s.log(ctx).Debug("retrieved song ids", zap.Int("count", len(ids)))
+	return ids, nil
+}
+
+// UpdateEnrichment сохраняет обогащенные поля и состояние обогащения песни, включая нулевые значения.
+// Обновление применяется, только если песня не менялась с момента чтения old, иначе возвращается ErrSongModified
+func (s *SongRepository) UpdateEnrichment(ctx context.Context, old, song *models.Song) error {
+	s.log(ctx).Debug("starting update enrichment",
+		zap.Uint("id", song.ID),
+		zap.String("status", string(song.EnrichmentStatus)))
+	result := s.conn(ctx, "UpdateEnrichment").Model(&models.Song{ID: song.ID}).
+		Where(`"group" = ? AND song = ? AND release_date = ? AND text = ? AND link = ?`,
+			old.Group, old.Song, old.ReleaseDate.Format("2006-01-02"), old.Text, old.Link).
+		Where("release_date_source = ? AND text_source = ? AND link_source = ?",
+			old.Sources.ReleaseDate, old.Sources.Text, old.Sources.Link).
+		Where("enrichment_status = ? AND enrichment_attempts = ?", old.EnrichmentStatus, old.EnrichmentAttempts).
+		Select("release_date", "text", "lyrics", "link",
+			"release_date_source", "text_source", "link_source",
+			"enrichment_status", "enrichment_attempts", "enrichment_error", "enriched_at").
+		Updates(song)
+	if result.Error != nil {
+		s.log(ctx).Error("failed to update enrichment",
+			zap.Uint("id", song.ID),
+			zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		err := s.conn(ctx, "UpdateEnrichment").Model(&models.Song{}).Where("id = ?", song.ID).Count(&count).Error
+		if err != nil {
+			s.log(ctx).Error("failed to check song existence",
+				zap.Uint("id", song.ID),
+				zap.Error(err))
+			return err
+		}
+		if count == 0 {
+			s.log(ctx).Warn("no song updated", zap.Uint("id", song.ID))
+			return gorm.ErrRecordNotFound
+		}
+		s.log(ctx).Warn("song modified concurrently, enrichment not saved", zap.Uint("id", song.ID))
+		return ErrSongModified
+	}
+	s.log(ctx).Debug("enrichment updated successfully", zap.Uint("id", song.ID))
+	return nil
+}
+
+// GetSongIDsWithoutLyrics возвращает ID песен, для которых еще не построен структурированный текст
+func (s *SongRepository) GetSongIDsWithoutLyrics(ctx context.Context) ([]uint, error) {
+	var ids []uint
+	err := s.conn(ctx, "GetSongIDsWithoutLyrics").Model(&models.Song{}).
+		Where("lyrics IS NULL").
+		Order("id").
+		Pluck("id", &ids).Error
+	if err != nil {
+		s.log(ctx).Error("failed to get song ids without lyrics", zap.Error(err))
+		return nil, err
+	}
+	return ids, nil
+}
+
+// UpdateLyrics сохраняет структурированный текст песни, не меняя остальные поля
+func (s *SongRepository) UpdateLyrics(ctx context.Context, id uint, structured *lyrics.Structured) error {
+	result := s.conn(ctx, "UpdateLyrics").Model(&models.Song{ID: id}).
+		Update("lyrics", structured)
+	if result.Error != nil {
+		s.log(ctx).Error("failed to update lyrics",
+			zap.Uint("id", id),
+			zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateSyncedLyrics сохраняет синхронизированный текст песни, не меняя остальные поля
+func (s *SongRepository) UpdateSyncedLyrics(ctx context.Context, id uint, synced *lyrics.Synced) error {
+	s.log(ctx).Debug("starting update synced lyrics",
+		zap.Uint("id", id),
+		zap.Bool("clear", synced == nil))
+	var value interface{} = synced
+	if synced == nil {
+		value = gorm.Expr("NULL")
+	}
+	result := s.conn(ctx, "UpdateSyncedLyrics").Model(&models.Song{ID: id}).
+		Update("synced_lyrics", value)
+	if result.Error != nil {
+		s.log(ctx).Error("failed to update synced lyrics",
+			zap.Uint("id", id),
+			zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		s.log(ctx).Warn("no song updated", zap.Uint("id", id))
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetStaleSongIDs возвращает ID обогащенных песен, у которых есть поля не из ручного ввода
+// и которые не обновлялись с момента before
+func (s *SongRepository) GetStaleSongIDs(ctx context.Context, before time.Time) ([]uint, error) {
+	s.log(ctx).Debug("starting get stale song ids", zap.Time("before", before))
+	var ids []uint
+	err := s.conn(ctx, "GetStaleSongIDs").Model(&models.Song{}).
+		Where("enrichment_status = ?", models.EnrichmentEnriched).
+		Where("enriched_at IS NULL OR enriched_at < ?", before).
+		Where("NOT (release_date_source = ? AND text_source = ? AND link_source = ?)",
+			models.SourceManual, models.SourceManual, models.SourceManual).
+		Order("id").
+		Pluck("id", &ids).Error
+	if err != nil {
+		s.log(ctx).Error("failed to get stale song ids", zap.Error(err))
+		return nil, err
+	}
+	s.log(ctx).Debug("retrieved stale song ids", zap.Int("count", len(ids)))
+	return ids, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"github.com/jaam8/online_song_library/internal/models"
+	"time"
+)
+
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// SongStore хранилище песен: создание, чтение, изменение, удаление и списки.
+// При отсутствии песни методы возвращают gorm.ErrRecordNotFound
+type SongStore interface {
+	CreateSong(ctx context.Context, song *models.Song) (uint, error)
+	GetAllSongs(ctx context.Context, limit, offset int, filters []models.SongFilter, sort []models.SortField) ([]models.Song, int64, error)
+	GetSongsPage(ctx context.Context, filters []models.SongFilter, sort []models.SortField, cursor string, limit int, withTotal bool) (*models.SongPage, error)
+	CountSongs(ctx context.Context) (int64, error)
+	SearchSongs(ctx context.Context, query, language string, limit, offset int) ([]models.SongSearchHit, int64, error)
+	GetSong(ctx context.Context, id uint) (*models.Song, error)
+	UpdateSong(ctx context.Context, id uint, updatedSong models.Song) error
+	DeleteSong(ctx context.Context, id uint) error
+}
+
+// EnrichmentStore состояние фонового обогащения песен
+type EnrichmentStore interface {
+	GetSongIDsByEnrichmentStatus(ctx context.Context, status models.EnrichmentStatus) ([]uint, error)
+	UpdateEnrichment(ctx context.Context, old, song *models.Song) error
+	GetStaleSongIDs(ctx context.Context, before time.Time) ([]uint, error)
+}
+
+// LyricsStore структурированный и синхронизированный текст песен
+type LyricsStore interface {
+	GetSongIDsWithoutLyrics(ctx context.Context) ([]uint, error)
+	UpdateLyrics(ctx context.Context, id uint, structured *lyrics.Structured) error
+	UpdateSyncedLyrics(ctx context.Context, id uint, synced *lyrics.Synced) error
+}
+
+// Store все хранилища, с которыми работает сервис песен
+type Store interface {
+	SongStore
+	EnrichmentStore
+	LyricsStore
+	TranslationStore
+	RevisionStore
+}
+
+var (
+	_ Store = (*SongRepository)(nil)
+	_ Store = (*MemorySongRepository)(nil)
+)
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"github.com/jaam8/online_song_library/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSort = errors.New("invalid sort")
+
+// sortField поле, по которому разрешена сортировка: колонка в PostgreSQL, значение поля песни
+// и разбор значения из курсора
+type sortField struct {
+	column string
+	value  func(song *models.Song) interface{}
+	parse  func(raw string) (interface{}, error)
+}
+
+// sortFields белый список полей сортировки
+var sortFields = map[string]sortField{
+	"id": {
+		column: "id",
+		value:  func(song *models.Song) interface{} { return song.ID },
+		parse: func(raw string) (interface{}, error) {
+			id, err := strconv.ParseUint(raw, 10, 32)
+			return uint(id), err
+		},
+	},
+	"group": {
+		column: `"group"`,
+		value:  func(song *models.Song) interface{} { return song.Group },
+		parse:  func(raw string) (interface{}, error) { return raw, nil },
+	},
+	"song": {
+		column: "song",
+		value:  func(song *models.Song) interface{} { return song.Song },
+		parse:  func(raw string) (interface{}, error) { return raw, nil },
+	},
+	"release_date": {
+		column: "release_date",
+		value:  func(song *models.Song) interface{} { return song.ReleaseDate },
+		parse: func(raw string) (interface{}, error) {
+			return time.Parse(time.RFC3339Nano, raw)
+		},
+	},
+}
+
+// normalizeSort проверяет поля сортировки по белому списку и добавляет в конец id,
+// чтобы порядок был однозначным. id сортируется в том же направлении, что и последнее поле,
+// чтобы запрос мог пройти по индексу (field, id) в одну сторону
+func normalizeSort(sort []models.SortField) ([]models.SortField, error) {
+	seen := make(map[string]bool, len(sort))
+	for _, f := range sort {
+		if _, ok := sortFields[f.Field]; !ok {
+			return nil, fmt.Errorf("%w: unknown field %s", ErrInvalidSort, f.Field)
+		}
+		if seen[f.Field] {
+			return nil, fmt.Errorf("%w: duplicate field %s", ErrInvalidSort, f.Field)
+		}
+		seen[f.Field] = true
+	}
+	if seen["id"] {
+		return sort, nil
+	}
+	desc := len(sort) > 0 && sort[len(sort)-1].Desc
+	return append(sort[:len(sort):len(sort)], models.SortField{Field: "id", Desc: desc}), nil
+}
+
+// orderSQL собирает ORDER BY из нормализованных полей сортировки; reverse меняет направление всех полей
+func orderSQL(sort []models.SortField, reverse bool) string {
+	parts := make([]string, 0, len(sort))
+	for _, f := range sort {
+		part := sortFields[f.Field].column
+		if f.Desc != reverse {
+			part += " DESC"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compareSongs сравнивает песни по нормализованным полям сортировки
+func compareSongs(a, b *models.Song, sort []models.SortField) int {
+	for _, f := range sort {
+		field := sortFields[f.Field]
+		if c := compareValues(field.value(a), field.value(b)); c != 0 {
+			if f.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case uint:
+		return cmp.Compare(a, b.(uint))
+	case string:
+		return strings.Compare(a, b.(string))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	}
+	return 0
+}
+
+// formatValue сериализует значение поля сортировки для курсора
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
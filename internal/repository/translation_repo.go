@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"github.com/jaam8/online_song_library/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// TranslationStore переводы текста песен; при отсутствии перевода методы возвращают gorm.ErrRecordNotFound
+type TranslationStore interface {
+	GetTranslations(ctx context.Context, songID uint) ([]models.SongTranslation, error)
+	GetTranslation(ctx context.Context, songID uint, language string) (*models.SongTranslation, error)
+	SaveTranslation(ctx context.Context, translation *models.SongTranslation) (bool, error)
+	DeleteTranslation(ctx context.Context, songID uint, language string) error
+}
+
+var (
+	_ TranslationStore = (*SongRepository)(nil)
+	_ TranslationStore = (*MemorySongRepository)(nil)
+)
+
+// GetTranslations возвращает переводы песни в порядке языков
+func (s *SongRepository) GetTranslations(ctx context.Context, songID uint) ([]models.SongTranslation, error) {
+	translations := make([]models.SongTranslation, 0)
+	err := s.conn(ctx, "GetTranslations").
+		Where("song_id = ?", songID).
+		Order("language").
+		Find(&translations).Error
+	if err != nil {
+		s.log(ctx).Error("failed to get translations",
+			zap.Uint("song_id", songID),
+			zap.Error(err))
+		return nil, err
+	}
+	return translations, nil
+}
+
+func (s *SongRepository) GetTranslation(ctx context.Context, songID uint, language string) (*models.SongTranslation, error) {
+	var translation models.SongTranslation
+	result := s.conn(ctx, "GetTranslation").
+		Where("song_id = ? AND language = ?", songID, language).
+		Limit(1).
+		Find(&translation)
+	if result.Error != nil {
+		s.log(ctx).Error("failed to get translation",
+			zap.Uint("song_id", songID),
+			zap.String("language", language),
+			zap.Error(result.Error))
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &translation, nil
+}
+
+// SaveTranslation создает или заменяет перевод и сообщает, был ли он создан
+func (s *SongRepository) SaveTranslation(ctx context.Context, translation *models.SongTranslation) (bool, error) {
+	s.log(ctx).Debug("starting save translation",
+		zap.Uint("song_id", translation.SongID),
+		zap.String("language", translation.Language))
+	var row struct {
+		models.SongTranslation `gorm:"embedded"`
+		Inserted               bool
+	}
+	err := s.conn(ctx, "SaveTranslation").Raw(`
+		INSERT INTO song_translations (song_id, language, text)
+		VALUES (?, ?, ?)
+		ON CONFLICT (song_id, language) DO UPDATE SET text = EXCLUDED.text, updated_at = now()
+		RETURNING *, (xmax = 0) AS inserted`,
+		translation.SongID, translation.Language, translation.Text).
+		Scan(&row).Error
+	if err != nil {
+		s.log(ctx).Error("failed to save translation",
+			zap.Uint("song_id", translation.SongID),
+			zap.String("language", translation.Language),
+			zap.Error(err))
+		return false, err
+	}
+	*translation = row.SongTranslation
+	return row.Inserted, nil
+}
+
+func (s *SongRepository) DeleteTranslation(ctx context.Context, songID uint, language string) error {
+	result := s.conn(ctx, "DeleteTranslation").
+		Where("song_id = ? AND language = ?", songID, language).
+		Delete(&models.SongTranslation{})
+	if result.Error != nil {
+		s.log(ctx).Error("failed to delete translation",
+			zap.Uint("song_id", songID),
+			zap.String("language", language),
+			zap.Error(result.Error))
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
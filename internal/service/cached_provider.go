@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/pkg/logger"
+	"go.uber.org/zap"
+	"time"
+)
+
+// CachedProvider кеширует ответы провайдера, включая отсутствие песни (negative caching)
+type CachedProvider struct {
+	next        SongInfoProvider
+	cache       SongInfoCache
+	ttl         time.Duration
+	negativeTTL time.Duration
+	l           *zap.Logger
+}
+
+func NewCachedProvider(next SongInfoProvider, cache SongInfoCache, cfg CacheConfig, log *zap.Logger) *CachedProvider {
+	return &CachedProvider{
+		next:        next,
+		cache:       cache,
+		ttl:         cfg.TTL,
+		negativeTTL: cfg.NegativeTTL,
+		l:           log,
+	}
+}
+
+type bypassCacheKey struct{}
+
+// withoutCache помечает ctx так, что CachedProvider обращается к провайдеру напрямую, не читая и не меняя кеш
+func withoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// log возвращает логгер запроса из ctx, а если его нет — логгер провайдера
+func (p *CachedProvider) log(ctx context.Context) *zap.Logger {
+	return logger.FromContextOr(ctx, p.l)
+}
+
+func (p *CachedProvider) GetSongInfo(ctx context.Context, group, song string) (*models.SongInfo, error) {
+	if bypass, _ := ctx.Value(bypassCacheKey{}).(bool); bypass {
+		p.log(ctx).Debug("song info cache bypassed",
+			zap.String("group", group),
+			zap.String("song", song))
+		return p.next.GetSongInfo(ctx, group, song)
+	}
+	entry, ok, err := p.cache.Get(ctx, group, song)
+	if err != nil {
+		p.log(ctx).Warn("failed to read song info cache", zap.Error(err))
+	}
+	if ok {
+		p.log(ctx).Debug("song info cache hit",
+			zap.String("group", group),
+			zap.String("song", song),
+			zap.Bool("not_found", entry.Info == nil))
+		if entry.Info == nil {
+			return nil, ErrSongInfoNotFound
+		}
+		info := *entry.Info
+		return &info, nil
+	}
+	p.log(ctx).Debug("song info cache miss",
+		zap.String("group", group),
+		zap.String("song", song))
+
+	info, err := p.next.GetSongInfo(ctx, group, song)
+	switch {
+	case err == nil && p.ttl > 0:
+		cached := *info
+		p.store(ctx, &models.SongInfoCacheEntry{
+			Group:     group,
+			Song:      song,
+			Info:      &cached,
+			ExpiresAt: time.Now().Add(p.ttl),
+		})
+	case errors.Is(err, ErrSongInfoNotFound) && p.negativeTTL > 0:
+		p.store(ctx, &models.SongInfoCacheEntry{
+			Group:     group,
+			Song:      song,
+			ExpiresAt: time.Now().Add(p.negativeTTL),
+		})
+	}
+	return info, err
+}
+
+func (p *CachedProvider) store(ctx context.Context, entry *models.SongInfoCacheEntry) {
+	if err := p.cache.Set(ctx, entry); err != nil {
+		p.log(ctx).Warn("failed to write song info cache", zap.Error(err))
+	}
+}
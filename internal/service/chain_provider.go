@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	ProviderSwagger   = "swagger"
+	ProviderFixtures  = "fixtures"
+	ProviderSecondary = "secondary"
+)
+
+type ProvidersConfig struct {
+	Order        []string `yaml:"INFO_PROVIDERS" env:"INFO_PROVIDERS" env-default:"swagger" env-separator:","`
+	FixturesDir  string   `yaml:"FIXTURES_DIR" env:"FIXTURES_DIR"`
+	SecondaryURL string   `yaml:"SECONDARY_INFO_URL" env:"SECONDARY_INFO_URL"`
+}
+
+// NamedProvider провайдер с именем, под которым он записывается в источники полей песни
+type NamedProvider struct {
+	Name     string
+	Provider SongInfoProvider
+}
+
+// ChainProvider опрашивает провайдеров по порядку и собирает каждое поле
+// из первого провайдера, который его вернул
+type ChainProvider struct {
+	providers []NamedProvider
+	l         *zap.Logger
+}
+
+func NewChainProvider(providers []NamedProvider, log *zap.Logger) *ChainProvider {
+	return &ChainProvider{providers: providers, l: log}
+}
+
+// log возвращает логгер запроса из ctx, а если его нет — логгер провайдера
+func (c *ChainProvider) log(ctx context.Context) *zap.Logger {
+	return logger.FromContextOr(ctx, c.l)
+}
+
+// NewProviders собирает цепочку провайдеров в порядке cfg.Order;
+// secondary использует настройки таймаутов и повторов основного API
+func NewProviders(cfg ProvidersConfig, swagger InfoClientConfig, log *zap.Logger) (*ChainProvider, error) {
+	providers := make([]NamedProvider, 0, len(cfg.Order))
+	for _, name := range cfg.Order {
+		var (
+			p   SongInfoProvider
+			err error
+		)
+		providerLog := log.With(zap.String("provider", name))
+		switch name {
+		case ProviderSwagger:
+			p = NewInfoClient(name, swagger, providerLog)
+		case ProviderSecondary:
+			if cfg.SecondaryURL == "" {
+				return nil, errors.New("SECONDARY_INFO_URL is required for secondary provider")
+			}
+			secondary := swagger
+			secondary.URL = cfg.SecondaryURL
+			p = NewInfoClient(name, secondary, providerLog)
+		case ProviderFixtures:
+			if cfg.FixturesDir == "" {
+				return nil, errors.New("FIXTURES_DIR is required for fixtures provider")
+			}
+			if p, err = NewFixtureProvider(cfg.FixturesDir, providerLog); err != nil {
+				return nil, fmt.Errorf("failed to load fixtures: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown info provider %q", name)
+		}
+		providers = append(providers, NamedProvider{Name: name, Provider: p})
+	}
+	if len(providers) == 0 {
+		return nil, errors.New("no info providers configured")
+	}
+	return NewChainProvider(providers, log), nil
+}
+
+func (c *ChainProvider) GetSongInfo(ctx context.Context, group, song string) (*models.SongInfo, error) {
+	var (
+		merged   models.SongInfo
+		firstErr error
+	)
+	for _, np := range c.providers {
+		info, err := np.Provider.GetSongInfo(ctx, group, song)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			if !errors.Is(err, ErrSongInfoNotFound) && firstErr == nil {
+				firstErr = err
+			}
+			c.log(ctx).Debug("provider returned no song info",
+				zap.String("provider", np.Name),
+				zap.Error(err))
+			continue
+		}
+		mergeSongInfo(&merged, info, np.Name)
+		if isComplete(&merged) {
+			break
+		}
+	}
+
+	if isEmpty(&merged) {
+		if firstErr != nil {
+			return nil, firstErr
+		}
+		return nil, ErrSongInfoNotFound
+	}
+	// неполный результат отдается вместе с ошибкой упавшего провайдера: недостающие поля могут быть заданы вручную,
+	// а если их все же не хватит, SongService вернет эту ошибку вместо «не найдено»
+	if !isComplete(&merged) {
+		c.log(ctx).Debug("song info is incomplete",
+			zap.String("group", group),
+			zap.String("song", song),
+			zap.Any("sources", merged.Sources),
+			zap.Error(firstErr))
+		return &merged, firstErr
+	}
+	c.log(ctx).Debug("song info merged",
+		zap.String("group", group),
+		zap.String("song", song),
+		zap.Any("sources", merged.Sources))
+	return &merged, nil
+}
+
+// Pinger провайдер, доступность которого можно проверить без запроса конкретной песни
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping проверяет доступность всех провайдеров цепочки, которые это поддерживают
+func (c *ChainProvider) Ping(ctx context.Context) error {
+	var errs []error
+	for _, np := range c.providers {
+		p, ok := np.Provider.(Pinger)
+		if !ok {
+			continue
+		}
+		if err := p.Ping(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", np.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// mergeSongInfo заполняет пустые поля dst значениями из src
+func mergeSongInfo(dst, src *models.SongInfo, name string) {
+	if dst.ReleaseDate.IsZero() && !src.ReleaseDate.IsZero() {
+		dst.ReleaseDate = src.ReleaseDate
+		dst.Sources.ReleaseDate = sourceName(src.Sources.ReleaseDate, name)
+	}
+	if dst.Text == "" && src.Text != "" {
+		dst.Text = src.Text
+		dst.Sources.Text = sourceName(src.Sources.Text, name)
+	}
+	if dst.Link == "" && src.Link != "" {
+		dst.Link = src.Link
+		dst.Sources.Link = sourceName(src.Sources.Link, name)
+	}
+}
+
+func sourceName(source, fallback string) string {
+	if source != "" {
+		return source
+	}
+	return fallback
+}
+
+func isComplete(info *models.SongInfo) bool {
+	return !info.ReleaseDate.IsZero() && info.Text != "" && info.Link != ""
+}
+
+func isEmpty(info *models.SongInfo) bool {
+	return info.ReleaseDate.IsZero() && info.Text == "" && info.Link == ""
+}
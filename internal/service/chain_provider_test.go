@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// stubProvider возвращает заданный ответ для любой песни
+type stubProvider struct {
+	info *models.SongInfo
+	err  error
+}
+
+func (p stubProvider) GetSongInfo(context.Context, string, string) (*models.SongInfo, error) {
+	return p.info, p.err
+}
+
+func TestChainProviderMerge(t *testing.T) {
+	releaseDate := time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC)
+	partial := &models.SongInfo{Text: "fixture text"}
+	full := &models.SongInfo{ReleaseDate: releaseDate, Text: "upstream text", Link: "https://example.com"}
+	upstreamErr := errors.New("status 500")
+
+	tests := []struct {
+		name      string
+		providers []stubProvider
+		wantErr   error
+		wantText  string
+		wantLink  string
+		wantDate  time.Time
+	}{
+		{
+			name:      "fields from first provider that has them",
+			providers: []stubProvider{{info: partial}, {info: full}},
+			wantText:  "fixture text",
+			wantLink:  "https://example.com",
+			wantDate:  releaseDate,
+		},
+		{
+			name:      "partial result with failed provider",
+			providers: []stubProvider{{info: partial}, {err: upstreamErr}},
+			wantErr:   upstreamErr,
+			wantText:  "fixture text",
+		},
+		{
+			name:      "failed provider before partial result",
+			providers: []stubProvider{{err: upstreamErr}, {info: partial}},
+			wantErr:   upstreamErr,
+			wantText:  "fixture text",
+		},
+		{
+			name:      "partial result without errors",
+			providers: []stubProvider{{info: partial}, {err: ErrSongInfoNotFound}},
+			wantText:  "fixture text",
+		},
+		{
+			name:      "nothing found",
+			providers: []stubProvider{{err: ErrSongInfoNotFound}, {err: ErrSongInfoNotFound}},
+			wantErr:   ErrSongInfoNotFound,
+		},
+		{
+			name:      "error from first provider is ignored when merge is complete",
+			providers: []stubProvider{{err: upstreamErr}, {info: full}},
+			wantText:  "upstream text",
+			wantLink:  "https://example.com",
+			wantDate:  releaseDate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers := make([]NamedProvider, len(tt.providers))
+			for i, p := range tt.providers {
+				providers[i] = NamedProvider{Name: "p" + string(rune('1'+i)), Provider: p}
+			}
+			info, err := NewChainProvider(providers, zap.NewNop()).GetSongInfo(context.Background(), "Muse", "Uprising")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantText == "" {
+				if info != nil {
+					t.Fatalf("expected no song info, got %+v", info)
+				}
+				return
+			}
+			if info == nil || info.Text != tt.wantText || info.Link != tt.wantLink || !info.ReleaseDate.Equal(tt.wantDate) {
+				t.Errorf("unexpected merge result %+v", info)
+			}
+		})
+	}
+}
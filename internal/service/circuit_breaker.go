@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrUpstreamFailed      = errors.New("upstream request failed")
+)
+
+// CircuitOpenError возвращается, пока circuit breaker не пропускает запросы к внешнему API
+type CircuitOpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker is open, retry after %s", e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrUpstreamUnavailable
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker размыкает цепь после threshold неудачных вызовов подряд
+// и пропускает один пробный вызов по истечении cooldown
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     breakerState
+	openedAt  time.Time
+	probing   bool
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow проверяет, можно ли выполнить вызов; если нет — возвращает *CircuitOpenError
+func (b *CircuitBreaker) Allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		elapsed := time.Since(b.openedAt)
+		if elapsed < b.cooldown {
+			return &CircuitOpenError{RetryAfter: b.cooldown - elapsed}
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			return &CircuitOpenError{RetryAfter: b.cooldown}
+		}
+		b.probing = true
+	}
+	return nil
+}
+
+// Success сбрасывает счетчик ошибок и замыкает цепь
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.state = breakerClosed
+	b.probing = false
+}
+
+// Failure учитывает неудачный вызов и при необходимости размыкает цепь
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Abort снимает пробный вызов, прерванный без результата, не меняя состояние цепи
+func (b *CircuitBreaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
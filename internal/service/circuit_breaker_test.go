@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := NewCircuitBreaker(2, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("call %d: unexpected error %v", i+1, err)
+		}
+		b.Failure()
+	}
+
+	err := b.Allow()
+	var openErr *CircuitOpenError
+	if !errors.As(err, &openErr) {
+		t.Fatalf("expected CircuitOpenError, got %v", err)
+	}
+	if !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("expected error to wrap ErrUpstreamUnavailable")
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	b := NewCircuitBreaker(1, 10*time.Millisecond)
+	_ = b.Allow()
+	b.Failure()
+	time.Sleep(20 * time.Millisecond)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe call should be allowed, got %v", err)
+	}
+	if err := b.Allow(); err == nil {
+		t.Fatal("second call during probe should be rejected")
+	}
+
+	b.Failure()
+	if err := b.Allow(); err == nil {
+		t.Fatal("failed probe should open the circuit again")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe call should be allowed, got %v", err)
+	}
+	b.Success()
+	if err := b.Allow(); err != nil {
+		t.Fatalf("successful probe should close the circuit, got %v", err)
+	}
+}
+
+func TestCircuitBreakerAbortKeepsState(t *testing.T) {
+	b := NewCircuitBreaker(1, 10*time.Millisecond)
+	_ = b.Allow()
+	b.Failure()
+	time.Sleep(20 * time.Millisecond)
+
+	_ = b.Allow()
+	b.Abort()
+	if b.state != breakerHalfOpen {
+		t.Fatalf("expected half-open state after abort, got %v", b.state)
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("next probe should be allowed after abort, got %v", err)
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := NewCircuitBreaker(0, time.Hour)
+	for i := 0; i < 10; i++ {
+		b.Failure()
+		if err := b.Allow(); err != nil {
+			t.Fatalf("disabled breaker should allow calls, got %v", err)
+		}
+	}
+}
+
+func TestInfoClientBadPayloadDoesNotCloseCircuit(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte("not json"))
+	}))
+	defer srv.Close()
+
+	client := NewInfoClient("test", InfoClientConfig{
+		URL:              srv.URL,
+		Timeout:          time.Second,
+		BreakerThreshold: 1,
+		BreakerCooldown:  10 * time.Millisecond,
+	}, zap.NewNop())
+
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrUpstreamFailed) {
+		t.Fatalf("expected ErrUpstreamFailed, got %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	status.Store(http.StatusOK)
+	if _, err := client.GetSongInfo(context.Background(), "Muse", "Uprising"); err == nil {
+		t.Fatal("expected bad payload error")
+	}
+	if client.breaker.state == breakerClosed {
+		t.Fatal("bad payload must not close the circuit")
+	}
+}
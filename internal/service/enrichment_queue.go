@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+type EnrichmentConfig struct {
+	Workers     int           `yaml:"ENRICHMENT_WORKERS" env:"ENRICHMENT_WORKERS" env-default:"4"`
+	QueueSize   int           `yaml:"ENRICHMENT_QUEUE_SIZE" env:"ENRICHMENT_QUEUE_SIZE" env-default:"100"`
+	MaxAttempts int           `yaml:"ENRICHMENT_MAX_ATTEMPTS" env:"ENRICHMENT_MAX_ATTEMPTS" env-default:"3"`
+	RetryDelay  time.Duration `yaml:"ENRICHMENT_RETRY_DELAY" env:"ENRICHMENT_RETRY_DELAY" env-default:"10s"`
+	JobTimeout  time.Duration `yaml:"ENRICHMENT_JOB_TIMEOUT" env:"ENRICHMENT_JOB_TIMEOUT" env-default:"1m"`
+}
+
+type jobKind int
+
+const (
+	// jobEnrich первичное обогащение песни в состоянии pending
+	jobEnrich jobKind = iota
+	// jobRefresh повторное обогащение уже сохраненной песни
+	jobRefresh
+)
+
+func (k jobKind) String() string {
+	if k == jobRefresh {
+		return "refresh"
+	}
+	return "enrich"
+}
+
+type enrichmentJob struct {
+	id   uint
+	kind jobKind
+}
+
+// EnrichmentQueue очередь песен, ожидающих фонового обогащения, и пул обрабатывающих ее воркеров
+type EnrichmentQueue struct {
+	jobs chan enrichmentJob
+	cfg  EnrichmentConfig
+	l    *zap.Logger
+	wg   sync.WaitGroup
+	// ctx создается в Start и живет до Stop: по нему останавливаются фоновые задачи, которые ставят песни в очередь
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewEnrichmentQueue(cfg EnrichmentConfig, log *zap.Logger) *EnrichmentQueue {
+	return &EnrichmentQueue{
+		jobs: make(chan enrichmentJob, cfg.QueueSize),
+		cfg:  cfg,
+		l:    log,
+		stop: make(chan struct{}),
+	}
+}
+
+// Enqueue добавляет песню в очередь без блокировки, возвращает false если очередь заполнена
+func (q *EnrichmentQueue) Enqueue(id uint) bool {
+	select {
+	case q.jobs <- enrichmentJob{id: id, kind: jobEnrich}:
+		q.l.Debug("song enqueued for enrichment", zap.Uint("id", id))
+		return true
+	default:
+		q.l.Warn("enrichment queue is full", zap.Uint("id", id))
+		return false
+	}
+}
+
+// EnqueuePending в фоне добавляет песни в очередь на первичное обогащение, ожидая свободного места
+func (q *EnrichmentQueue) EnqueuePending(ids []uint) {
+	q.enqueueAll(ids, jobEnrich)
+}
+
+// EnqueueRefresh в фоне добавляет песни в очередь на повторное обогащение, ожидая свободного места
+func (q *EnrichmentQueue) EnqueueRefresh(ids []uint) {
+	q.enqueueAll(ids, jobRefresh)
+}
+
+func (q *EnrichmentQueue) enqueueAll(ids []uint, kind jobKind) {
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		for _, id := range ids {
+			select {
+			case <-q.ctx.Done():
+				q.l.Warn("enqueue interrupted", zap.Uint("id", id), zap.Stringer("kind", kind))
+				return
+			case q.jobs <- enrichmentJob{id: id, kind: kind}:
+				q.l.Debug("song enqueued", zap.Uint("id", id), zap.Stringer("kind", kind))
+			}
+		}
+	}()
+}
+
+// Exhausted сообщает, что после attempts попыток песня больше не обогащается
+func (q *EnrichmentQueue) Exhausted(attempts int) bool {
+	return attempts >= q.cfg.MaxAttempts
+}
+
+// Retry в фоне возвращает песню в очередь через RetryDelay
+func (q *EnrichmentQueue) Retry(id uint) {
+	q.enqueueAfter(id, q.cfg.RetryDelay)
+}
+
+// enqueueAfter в фоне добавляет песню в очередь по истечении delay, ожидая свободного места.
+// Если воркеры остановлены раньше, песня остается в состоянии pending и попадет в очередь при следующем запуске
+func (q *EnrichmentQueue) enqueueAfter(id uint, delay time.Duration) {
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-q.ctx.Done():
+			q.l.Warn("enrichment retry interrupted", zap.Uint("id", id))
+			return
+		case <-timer.C:
+		}
+		select {
+		case <-q.ctx.Done():
+			q.l.Warn("enrichment retry interrupted", zap.Uint("id", id))
+		case q.jobs <- enrichmentJob{id: id, kind: jobEnrich}:
+			q.l.Debug("song enqueued for enrichment retry", zap.Uint("id", id))
+		}
+	}()
+}
+
+// Start запускает воркеры, которые обрабатывают очередь до Stop или отмены ctx.
+// Каждая задача выполняется с таймаутом JobTimeout, отмена ctx прерывает и выполняющиеся задачи.
+// Фоновые постановки в очередь и Go допустимы только после Start
+func (q *EnrichmentQueue) Start(ctx context.Context, handle func(ctx context.Context, job enrichmentJob)) {
+	q.l.Info("starting enrichment workers", zap.Int("workers", q.cfg.Workers))
+	q.ctx, q.cancel = context.WithCancel(ctx)
+	for i := 0; i < q.cfg.Workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for ctx.Err() == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-q.stop:
+					q.drain(ctx, handle)
+					return
+				case job := <-q.jobs:
+					q.run(ctx, job, handle)
+				}
+			}
+		}()
+	}
+}
+
+// Go запускает фоновую задачу, которую Wait дожидается вместе с воркерами; ctx задачи отменяется при Stop
+func (q *EnrichmentQueue) Go(task func(ctx context.Context)) {
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		task(q.ctx)
+	}()
+}
+
+// Stop прекращает постановку новых задач: воркеры дообрабатывают то, что уже лежит в очереди, и завершаются.
+// Песни, не попавшие в очередь, остаются в состоянии pending до следующего запуска
+func (q *EnrichmentQueue) Stop() {
+	q.stopOnce.Do(func() {
+		q.l.Info("stopping enrichment queue", zap.Int("queued", len(q.jobs)))
+		if q.cancel != nil {
+			q.cancel()
+		}
+		close(q.stop)
+	})
+}
+
+// drain выполняет задачи, оставшиеся в очереди после Stop
+func (q *EnrichmentQueue) drain(ctx context.Context, handle func(ctx context.Context, job enrichmentJob)) {
+	for ctx.Err() == nil {
+		select {
+		case job := <-q.jobs:
+			q.run(ctx, job, handle)
+		default:
+			return
+		}
+	}
+}
+
+// Wait дожидается завершения всех воркеров и фоновых задач
+func (q *EnrichmentQueue) Wait() {
+	q.wg.Wait()
+	q.l.Info("enrichment workers stopped")
+}
+
+func (q *EnrichmentQueue) run(ctx context.Context, job enrichmentJob, handle func(ctx context.Context, job enrichmentJob)) {
+	if q.cfg.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.cfg.JobTimeout)
+		defer cancel()
+	}
+	handle(ctx, job)
+}
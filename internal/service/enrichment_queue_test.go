@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestEnqueueAfterWaitsForFreeSlot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := NewEnrichmentQueue(EnrichmentConfig{QueueSize: 1}, zap.NewNop())
+	q.Start(ctx, nil)
+
+	if !q.Enqueue(1) {
+		t.Fatal("first song should fit into the queue")
+	}
+	q.enqueueAfter(2, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if job := <-q.jobs; job.id != 1 {
+		t.Fatalf("expected song 1, got %d", job.id)
+	}
+	select {
+	case job := <-q.jobs:
+		if job.id != 2 || job.kind != jobEnrich {
+			t.Fatalf("expected enrichment of song 2, got %+v", job)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("retry was lost while the queue was full")
+	}
+	q.wg.Wait()
+}
+
+func TestEnqueueAfterStopsWithQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := NewEnrichmentQueue(EnrichmentConfig{QueueSize: 1}, zap.NewNop())
+	q.Start(ctx, nil)
+
+	q.Enqueue(1)
+	q.enqueueAfter(2, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("retry goroutine did not stop after queue context was canceled")
+	}
+}
+
+func TestStopDrainsQueuedJobs(t *testing.T) {
+	q := NewEnrichmentQueue(EnrichmentConfig{Workers: 1, QueueSize: 10}, zap.NewNop())
+	var handled []uint
+	release := make(chan struct{})
+	q.Start(context.Background(), func(ctx context.Context, job enrichmentJob) {
+		<-release
+		handled = append(handled, job.id)
+	})
+	for id := uint(1); id <= 3; id++ {
+		q.Enqueue(id)
+	}
+
+	taskCanceled := make(chan struct{})
+	q.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(taskCanceled)
+	})
+
+	q.Stop()
+	close(release)
+	q.Wait()
+
+	if len(handled) != 3 {
+		t.Fatalf("expected all queued jobs to be handled, got %v", handled)
+	}
+	select {
+	case <-taskCanceled:
+	default:
+		t.Fatal("background task context must be canceled by Stop")
+	}
+}
+
+func TestCanceledWorkersDropQueuedJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	q := NewEnrichmentQueue(EnrichmentConfig{Workers: 1, QueueSize: 10}, zap.NewNop())
+	started := make(chan struct{})
+	var handled int
+	q.Start(ctx, func(ctx context.Context, job enrichmentJob) {
+		handled++
+		close(started)
+		<-ctx.Done()
+	})
+	q.Enqueue(1)
+	q.Enqueue(2)
+	<-started
+
+	q.Stop()
+	cancel()
+	q.Wait()
+
+	if handled != 1 {
+		t.Fatalf("expected only the in-flight job to run, got %d", handled)
+	}
+}
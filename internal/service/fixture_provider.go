@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/pkg/fakeinfo"
+	"github.com/jaam8/online_song_library/pkg/logger"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FixtureProvider отдает информацию о песнях из json- и yaml-файлов каталога в формате фикстур fakeinfo.
+// Поля в фикстурах могут быть заполнены частично
+type FixtureProvider struct {
+	songs map[string]models.SongInfo
+	l     *zap.Logger
+}
+
+func NewFixtureProvider(dir string, log *zap.Logger) (*FixtureProvider, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &FixtureProvider{songs: make(map[string]models.SongInfo), l: log}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		fixtures, err := fakeinfo.LoadFixtures(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fixtures {
+			var releaseDate time.Time
+			if f.ReleaseDate != "" {
+				if releaseDate, err = parseReleaseDate(f.ReleaseDate); err != nil {
+					return nil, err
+				}
+			}
+			p.songs[fixtureKey(f.Group, f.Song)] = models.SongInfo{
+				ReleaseDate: releaseDate,
+				Text:        f.Text,
+				Link:        f.Link,
+			}
+		}
+	}
+	log.Info("fixtures loaded", zap.String("dir", dir), zap.Int("songs", len(p.songs)))
+	return p, nil
+}
+
+// log возвращает логгер запроса из ctx с именем провайдера, а если его нет — логгер провайдера
+func (p *FixtureProvider) log(ctx context.Context) *zap.Logger {
+	if l, ok := logger.FromContext(ctx); ok {
+		return l.With(zap.String("provider", ProviderFixtures))
+	}
+	return p.l
+}
+
+func (p *FixtureProvider) GetSongInfo(ctx context.Context, group, song string) (*models.SongInfo, error) {
+	info, ok := p.songs[fixtureKey(group, song)]
+	if !ok {
+		p.log(ctx).Debug("song not found in fixtures",
+			zap.String("group", group),
+			zap.String("song", song))
+		return nil, ErrSongInfoNotFound
+	}
+	return &info, nil
+}
+
+func fixtureKey(group, song string) string {
+	return strings.ToLower(group) + "\x00" + strings.ToLower(song)
+}
@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jaam8/online_song_library/internal/repository"
+	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/jaam8/online_song_library/pkg/fakeinfo"
+	"go.uber.org/zap"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testFixtures = []fakeinfo.Fixture{
+	{
+		Group: "Muse",
+		Song:  "Supermassive Black Hole",
+		SongDetail: fakeinfo.SongDetail{
+			ReleaseDate: "16.07.2006",
+			Text:        "Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\n\nOoh\nYou set my soul alight",
+			Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+		},
+	},
+}
+
+// testOptions настройки сервиса, который собирает newTestService
+type testOptions struct {
+	fake fakeinfo.Options
+	// fixtures опрашиваются через провайдер fixtures до фейкового /info
+	fixtures []fakeinfo.Fixture
+	// cache включает кеш ответов /info в памяти
+	cache bool
+	// workers запускает воркеры обогащения, которые останавливаются в конце теста
+	workers int
+}
+
+// testEnv сервис и его зависимости, к которым тесты обращаются напрямую
+type testEnv struct {
+	s     *service.SongService
+	repo  *repository.MemorySongRepository
+	fake  *fakeinfo.Server
+	cache *service.MemoryInfoCache
+}
+
+// newTestService собирает сервис на хранилище в памяти и фейковом /info
+func newTestService(t *testing.T, opts testOptions) *testEnv {
+	t.Helper()
+	fake, srv := fakeinfo.NewTestServer(testFixtures, opts.fake)
+	t.Cleanup(srv.Close)
+
+	log := zap.NewNop()
+	env := &testEnv{repo: repository.NewMemory(log), fake: fake}
+	var provider service.SongInfoProvider = service.NewInfoClient(service.ProviderSwagger, service.InfoClientConfig{
+		URL:              srv.URL + "/info",
+		Timeout:          time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Minute,
+	}, log)
+	if opts.fixtures != nil {
+		dir := t.TempDir()
+		data, err := json.Marshal(opts.fixtures)
+		if err != nil {
+			t.Fatalf("marshal fixtures: %v", err)
+		}
+		if err = os.WriteFile(filepath.Join(dir, "fixtures.json"), data, 0o644); err != nil {
+			t.Fatalf("write fixtures: %v", err)
+		}
+		fixtures, err := service.NewFixtureProvider(dir, log)
+		if err != nil {
+			t.Fatalf("NewFixtureProvider: %v", err)
+		}
+		provider = service.NewChainProvider([]service.NamedProvider{
+			{Name: service.ProviderFixtures, Provider: fixtures},
+			{Name: service.ProviderSwagger, Provider: provider},
+		}, log)
+	}
+	var cache service.SongInfoCache
+	if opts.cache {
+		env.cache = service.NewMemoryInfoCache(10)
+		cache = env.cache
+		provider = service.NewCachedProvider(provider, cache, service.CacheConfig{TTL: time.Hour}, log)
+	}
+	queue := service.NewEnrichmentQueue(service.EnrichmentConfig{
+		Workers:     opts.workers,
+		QueueSize:   10,
+		MaxAttempts: 3,
+	}, log)
+	env.s = service.New(env.repo, provider, cache, queue, service.SearchConfig{}, log)
+	if opts.workers > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := env.s.StartEnrichment(ctx); err != nil {
+			t.Fatalf("StartEnrichment: %v", err)
+		}
+		t.Cleanup(func() {
+			queue.Stop()
+			cancel()
+			queue.Wait()
+		})
+	}
+	return env
+}
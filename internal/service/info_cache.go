@@ -0,0 +1,111 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"github.com/jaam8/online_song_library/internal/models"
+	"sync"
+	"time"
+)
+
+const (
+	CacheBackendNone     = "none"
+	CacheBackendMemory   = "memory"
+	CacheBackendPostgres = "postgres"
+)
+
+type CacheConfig struct {
+	Backend     string        `yaml:"CACHE_BACKEND" env:"CACHE_BACKEND" env-default:"memory"`
+	Size        int           `yaml:"CACHE_SIZE" env:"CACHE_SIZE" env-default:"1000"`
+	TTL         time.Duration `yaml:"CACHE_TTL" env:"CACHE_TTL" env-default:"24h"`
+	NegativeTTL time.Duration `yaml:"CACHE_NEGATIVE_TTL" env:"CACHE_NEGATIVE_TTL" env-default:"10m"`
+}
+
+// SongInfoCache хранилище ответов внешнего API по названию группы и песни
+type SongInfoCache interface {
+	Get(ctx context.Context, group, song string) (*models.SongInfoCacheEntry, bool, error)
+	Set(ctx context.Context, entry *models.SongInfoCacheEntry) error
+	Delete(ctx context.Context, group, song string) (int64, error)
+	Purge(ctx context.Context) (int64, error)
+}
+
+// MemoryInfoCache потокобезопасный LRU-кеш в памяти процесса
+type MemoryInfoCache struct {
+	mu      sync.Mutex
+	size    int
+	items   map[string]*list.Element
+	entries *list.List
+}
+
+func NewMemoryInfoCache(size int) *MemoryInfoCache {
+	return &MemoryInfoCache{
+		size:    size,
+		items:   make(map[string]*list.Element),
+		entries: list.New(),
+	}
+}
+
+func cacheKey(group, song string) string {
+	return group + "\x00" + song
+}
+
+func (c *MemoryInfoCache) Get(_ context.Context, group, song string) (*models.SongInfoCacheEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[cacheKey(group, song)]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*models.SongInfoCacheEntry)
+	if time.Now().After(entry.ExpiresAt) {
+		c.remove(el)
+		return nil, false, nil
+	}
+	c.entries.MoveToFront(el)
+	return entry, true, nil
+}
+
+func (c *MemoryInfoCache) Set(_ context.Context, entry *models.SongInfoCacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(entry.Group, entry.Song)
+	if el, ok := c.items[key]; ok {
+		el.Value = entry
+		c.entries.MoveToFront(el)
+		return nil
+	}
+	c.items[key] = c.entries.PushFront(entry)
+	for c.size > 0 && c.entries.Len() > c.size {
+		c.remove(c.entries.Back())
+	}
+	return nil
+}
+
+func (c *MemoryInfoCache) Delete(_ context.Context, group, song string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[cacheKey(group, song)]
+	if !ok {
+		return 0, nil
+	}
+	c.remove(el)
+	return 1, nil
+}
+
+func (c *MemoryInfoCache) Purge(_ context.Context) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := int64(c.entries.Len())
+	c.items = make(map[string]*list.Element)
+	c.entries.Init()
+	return n, nil
+}
+
+func (c *MemoryInfoCache) remove(el *list.Element) {
+	entry := c.entries.Remove(el).(*models.SongInfoCacheEntry)
+	delete(c.items, cacheKey(entry.Group, entry.Song))
+}
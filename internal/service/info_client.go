@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jaam8/online_song_library/internal/metrics"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/tracing"
+	"github.com/jaam8/online_song_library/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type InfoClientConfig struct {
+	URL              string        `yaml:"SWAGGER_URL" env:"SWAGGER_URL"`
+	Timeout          time.Duration `yaml:"SWAGGER_TIMEOUT" env:"SWAGGER_TIMEOUT" env-default:"5s"`
+	MaxRetries       int           `yaml:"SWAGGER_MAX_RETRIES" env:"SWAGGER_MAX_RETRIES" env-default:"3"`
+	RetryBaseDelay   time.Duration `yaml:"SWAGGER_RETRY_BASE_DELAY" env:"SWAGGER_RETRY_BASE_DELAY" env-default:"200ms"`
+	RetryMaxDelay    time.Duration `yaml:"SWAGGER_RETRY_MAX_DELAY" env:"SWAGGER_RETRY_MAX_DELAY" env-default:"2s"`
+	BreakerThreshold int           `yaml:"SWAGGER_BREAKER_THRESHOLD" env:"SWAGGER_BREAKER_THRESHOLD" env-default:"5"`
+	BreakerCooldown  time.Duration `yaml:"SWAGGER_BREAKER_COOLDOWN" env:"SWAGGER_BREAKER_COOLDOWN" env-default:"30s"`
+}
+
+// retryableError ошибка, после которой запрос к API имеет смысл повторить (5xx или сетевая ошибка)
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// InfoClient получает информацию о песне из API, описанного сваггером (/info)
+type InfoClient struct {
+	name    string
+	client  *http.Client
+	breaker *CircuitBreaker
+	cfg     InfoClientConfig
+	l       *zap.Logger
+}
+
+// NewInfoClient создает клиент API; name используется как метка провайдера в метриках
+func NewInfoClient(name string, cfg InfoClientConfig, log *zap.Logger) *InfoClient {
+	return &InfoClient{
+		name:    name,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		breaker: NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		cfg:     cfg,
+		l:       log,
+	}
+}
+
+// log возвращает логгер запроса из ctx с именем провайдера, а если его нет — логгер клиента
+func (c *InfoClient) log(ctx context.Context) *zap.Logger {
+	if l, ok := logger.FromContext(ctx); ok {
+		return l.With(zap.String("provider", c.name))
+	}
+	return c.l
+}
+
+func (c *InfoClient) GetSongInfo(ctx context.Context, group, song string) (*models.SongInfo, error) {
+	if err := c.breaker.Allow(); err != nil {
+		c.log(ctx).Warn("swagger circuit breaker is open", zap.Error(err))
+		metrics.InfoRequests.WithLabelValues(c.name, metrics.OutcomeCircuitOpen).Inc()
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Add("group", group)
+	params.Add("song", song)
+	reqURL := fmt.Sprintf("%s?%s", c.cfg.URL, params.Encode())
+
+	var (
+		info *models.SongInfo
+		err  error
+	)
+	for attempt := 0; ; attempt++ {
+		info, err = c.fetch(ctx, reqURL)
+		if ctx.Err() != nil {
+			c.log(ctx).Warn("swagger request canceled", zap.Error(ctx.Err()))
+			c.breaker.Abort()
+			return nil, ctx.Err()
+		}
+		var retryErr *retryableError
+		if !errors.As(err, &retryErr) {
+			break
+		}
+		if attempt >= c.cfg.MaxRetries {
+			c.breaker.Failure()
+			c.log(ctx).Error("swagger request failed, retries exhausted",
+				zap.Int("attempts", attempt+1),
+				zap.Error(err))
+			return nil, fmt.Errorf("%w: %w", ErrUpstreamFailed, err)
+		}
+		delay := c.backoff(attempt)
+		c.log(ctx).Warn("swagger request failed, retrying",
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+		select {
+		case <-ctx.Done():
+			c.log(ctx).Warn("swagger request canceled", zap.Error(ctx.Err()))
+			c.breaker.Abort()
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	switch {
+	case err == nil, errors.Is(err, ErrSongInfoNotFound):
+		c.breaker.Success()
+	default:
+		// некорректный ответ или 4xx не говорят о доступности API: состояние цепи не меняется
+		c.breaker.Abort()
+	}
+	return info, err
+}
+
+// Ping проверяет, что API отвечает: любой ответ, кроме 5xx, считается успешным.
+// Запрос выполняется без повторов и не влияет на circuit breaker
+func (c *InfoClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("swagger error: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff возвращает экспоненциальную задержку перед повтором с небольшим джиттером
+func (c *InfoClient) backoff(attempt int) time.Duration {
+	delay := c.cfg.RetryBaseDelay << attempt
+	if delay <= 0 || delay > c.cfg.RetryMaxDelay {
+		delay = c.cfg.RetryMaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (c *InfoClient) fetch(ctx context.Context, reqURL string) (*models.SongInfo, error) {
+	c.log(ctx).Debug("sending request to swagger", zap.String("url", reqURL))
+
+	ctx, span := tracing.Tracer().Start(ctx, "GET /info",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(http.MethodGet),
+			semconv.URLFull(reqURL),
+			attribute.String("provider", c.name),
+		))
+	start := time.Now()
+	outcome := metrics.OutcomeNetwork
+	defer func() {
+		if ctx.Err() != nil {
+			outcome = metrics.OutcomeCanceled
+		}
+		metrics.InfoRequests.WithLabelValues(c.name, outcome).Inc()
+		metrics.InfoDuration.WithLabelValues(c.name, outcome).Observe(time.Since(start).Seconds())
+		span.SetAttributes(attribute.String("outcome", outcome))
+		if outcome != metrics.OutcomeOK && outcome != metrics.OutcomeNotFound {
+			span.SetStatus(codes.Error, outcome)
+		}
+		span.End()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		c.log(ctx).Error("failed to build swagger request", zap.Error(err))
+		outcome = metrics.OutcomeClientError
+		return nil, err
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := c.client.Do(req)
+	if err != nil {
+		c.log(ctx).Error("http get failed", zap.Error(err))
+		span.RecordError(err)
+		return nil, &retryableError{err}
+	}
+	defer resp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.log(ctx).Error("failed to read swagger response", zap.Error(err))
+		return nil, &retryableError{err}
+	}
+	c.log(ctx).Debug("received response from swagger",
+		zap.Int("status", resp.StatusCode),
+		zap.String("body", string(body)))
+
+	if resp.StatusCode == http.StatusNotFound {
+		c.log(ctx).Warn("song info not found in swagger")
+		outcome = metrics.OutcomeNotFound
+		return nil, ErrSongInfoNotFound
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		c.log(ctx).Error("swagger response not ok",
+			zap.Int("status", resp.StatusCode))
+		outcome = metrics.OutcomeServerError
+		return nil, &retryableError{fmt.Errorf("swagger error: status %d", resp.StatusCode)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		c.log(ctx).Error("swagger response not ok",
+			zap.Int("status", resp.StatusCode))
+		outcome = metrics.OutcomeClientError
+		return nil, fmt.Errorf("swagger error: status %d", resp.StatusCode)
+	}
+
+	info, err := decodeSongDetail(body)
+	if err != nil {
+		c.log(ctx).Error("invalid swagger response", zap.Error(err))
+		outcome = metrics.OutcomeBadPayload
+		return nil, err
+	}
+	outcome = metrics.OutcomeOK
+	c.log(ctx).Debug("decoded swagger response",
+		zap.Time("releaseDate", info.ReleaseDate))
+	return info, nil
+}
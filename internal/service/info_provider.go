@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+)
+
+var ErrSongInfoNotFound = errors.New("song info not found")
+
+// SongInfoProvider источник информации о песне по названию группы и песни.
+// Ответ может быть неполным, а вместе с ошибкой может вернуться частичный результат
+type SongInfoProvider interface {
+	GetSongInfo(ctx context.Context, group, song string) (*models.SongInfo, error)
+}
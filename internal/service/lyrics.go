@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"github.com/jaam8/online_song_library/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GetLyrics возвращает текст песни в плоском и структурированном виде. Для песен, у которых
+// структура еще не построена, она разбирается из текста на лету
+func (s *SongService) GetLyrics(ctx context.Context, id uint) (string, *lyrics.Structured, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.GetLyrics",
+		trace.WithAttributes(attribute.Int("song.id", int(id))))
+	defer span.End()
+	song, err := s.repo.GetSong(ctx, id)
+	if err != nil {
+		return "", nil, err
+	}
+	structured := song.Lyrics
+	if structured == nil {
+		s.log(ctx).Debug("lyrics are not structured yet, parsing text", zap.Uint("id", id))
+		structured = lyrics.Structure(song.Text)
+	}
+	return lyrics.Normalize(song.Text), structured, nil
+}
+
+// BackfillLyrics строит структурированный текст для песен, сохраненных до появления колонки lyrics.
+// Песни обрабатываются по одной, поэтому прерванное заполнение продолжится при следующем запуске
+func (s *SongService) BackfillLyrics(ctx context.Context) error {
+	ids, err := s.repo.GetSongIDsWithoutLyrics(ctx)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	s.log(ctx).Info("starting lyrics backfill", zap.Int("count", len(ids)))
+
+	done := 0
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		song, err := s.repo.GetSong(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err = s.repo.UpdateLyrics(ctx, id, lyrics.Structure(song.Text)); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		done++
+	}
+	s.log(ctx).Info("lyrics backfill finished", zap.Int("count", done))
+	return nil
+}
+
+var (
+	ErrSyncedLyricsNotFound = errors.New("synced lyrics not found")
+	ErrSyncedLyricsMismatch = errors.New("synced lyrics do not match the song text")
+)
+
+// songChanged вызывается после каждого сохраненного изменения полей песни: если изменился текст,
+// сбрасывает синхронизированный текст, строки которого больше с ним не сопоставляются, и записывает ревизию.
+// Ошибки только логируются: изменение песни уже сохранено
+func (s *SongService) songChanged(ctx context.Context, id uint, author, reason string) {
+	song, err := s.repo.GetSong(ctx, id)
+	if err != nil {
+		s.log(ctx).Error("failed to load changed song", zap.Uint("id", id), zap.Error(err))
+		return
+	}
+	latest, err := s.repo.GetLatestRevision(ctx, id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.log(ctx).Error("failed to load latest revision", zap.Uint("id", id), zap.Error(err))
+		return
+	}
+	// последняя ревизия хранит текст до изменения; без нее изменение текста не определить,
+	// и остается только проверка сопоставления строк
+	textChanged := latest == nil || latest.Text != song.Text
+	if textChanged && song.SyncedLyrics != nil && !song.SyncedLyrics.Matches(song.Text) {
+		if err = s.repo.UpdateSyncedLyrics(ctx, id, nil); err != nil {
+			s.log(ctx).Error("failed to clear stale synced lyrics", zap.Uint("id", id), zap.Error(err))
+		} else {
+			s.log(ctx).Info("synced lyrics cleared, song text changed", zap.Uint("id", id))
+		}
+	}
+	s.recordRevision(ctx, song, latest, author, reason)
+}
+
+// SetSyncedLyrics разбирает LRC и сохраняет метки времени строк песни.
+// Каждая непустая строка LRC должна сопоставляться со строкой текста песни, иначе возвращается ErrSyncedLyricsMismatch
+func (s *SongService) SetSyncedLyrics(ctx context.Context, id uint, lrc string) (*lyrics.Synced, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.SetSyncedLyrics",
+		trace.WithAttributes(attribute.Int("song.id", int(id))))
+	defer span.End()
+	synced, err := lyrics.ParseLRC(lrc)
+	if err != nil {
+		s.log(ctx).Debug("failed to parse lrc", zap.Uint("id", id), zap.Error(err))
+		return nil, err
+	}
+	song, err := s.repo.GetSong(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !synced.Matches(song.Text) {
+		s.log(ctx).Debug("lrc does not match song text", zap.Uint("id", id))
+		return nil, ErrSyncedLyricsMismatch
+	}
+	if err = s.repo.UpdateSyncedLyrics(ctx, id, synced); err != nil {
+		return nil, err
+	}
+	s.log(ctx).Info("synced lyrics saved",
+		zap.Uint("id", id),
+		zap.Int("lines", len(synced.Lines)))
+	return synced, nil
+}
+
+// GetSyncedLyrics возвращает синхронизированный текст песни и ее текст, с которым сопоставляются строки
+func (s *SongService) GetSyncedLyrics(ctx context.Context, id uint) (*lyrics.Synced, string, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.GetSyncedLyrics",
+		trace.WithAttributes(attribute.Int("song.id", int(id))))
+	defer span.End()
+	song, err := s.repo.GetSong(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	if song.SyncedLyrics == nil || len(song.SyncedLyrics.Lines) == 0 {
+		return nil, "", ErrSyncedLyricsNotFound
+	}
+	return song.SyncedLyrics, song.Text, nil
+}
@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/service"
+	"testing"
+)
+
+func TestSyncedLyricsFollowTextChanges(t *testing.T) {
+	s := newTestService(t, testOptions{}).s
+	ctx := context.Background()
+
+	raw := models.SongRaw{
+		Group:       "Muse",
+		Song:        "Uprising",
+		ReleaseDate: "07.09.2009",
+		Text:        "The paranoia is in bloom\nThe PR transmissions will resume",
+		Link:        "https://example.com/uprising",
+	}
+	id, err := s.CreateSong(ctx, raw)
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+	if _, err = s.SetSyncedLyrics(ctx, id, "[00:10.00]The paranoia is in bloom\n[00:14.00]The PR transmissions will resume"); err != nil {
+		t.Fatalf("SetSyncedLyrics: %v", err)
+	}
+
+	raw.Link = "https://example.com/uprising-live"
+	if err = s.UpdateSong(ctx, id, raw); err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+	if _, _, err = s.GetSyncedLyrics(ctx, id); err != nil {
+		t.Fatalf("synced lyrics must survive a link-only edit, got %v", err)
+	}
+
+	raw.Text = "The paranoia is in bloom\nThe PR transmissions will resume\nThey'll try to push drugs"
+	if err = s.UpdateSong(ctx, id, raw); err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+	if _, _, err = s.GetSyncedLyrics(ctx, id); err != nil {
+		t.Fatalf("added line keeps existing timings valid, got %v", err)
+	}
+
+	raw.Text = "They'll try to push drugs\nThat keep us all dumbed down"
+	if err = s.UpdateSong(ctx, id, raw); err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+	if _, _, err = s.GetSyncedLyrics(ctx, id); !errors.Is(err, service.ErrSyncedLyricsNotFound) {
+		t.Fatalf("stale synced lyrics must be cleared, got %v", err)
+	}
+}
+
+func TestSetSyncedLyricsRejectsForeignText(t *testing.T) {
+	s := newTestService(t, testOptions{}).s
+	ctx := context.Background()
+
+	id, err := s.CreateSong(ctx, models.SongRaw{
+		Group:       "Muse",
+		Song:        "Uprising",
+		ReleaseDate: "07.09.2009",
+		Text:        "The paranoia is in bloom\nThe PR transmissions will resume",
+		Link:        "https://example.com/uprising",
+	})
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+	_, err = s.SetSyncedLyrics(ctx, id, "[00:10.00]The paranoia is in bloom\n[00:14.00]Ooh baby, don't you know I suffer?")
+	if !errors.Is(err, service.ErrSyncedLyricsMismatch) {
+		t.Fatalf("SetSyncedLyrics error = %v, want %v", err, service.ErrSyncedLyricsMismatch)
+	}
+	if _, _, err = s.GetSyncedLyrics(ctx, id); !errors.Is(err, service.ErrSyncedLyricsNotFound) {
+		t.Fatalf("mismatched lrc must not be saved, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/repository"
+	"github.com/jaam8/online_song_library/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"time"
+)
+
+// RefreshSong заново запрашивает информацию о сохраненной песне и возвращает изменения полей.
+// Поля, заданные вручную, не обновляются; с dryRun изменения не сохраняются.
+// Если песню изменили во время запроса к API, возвращается repository.ErrSongModified
+func (s *SongService) RefreshSong(ctx context.Context, id uint, dryRun bool) ([]models.FieldChange, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.RefreshSong",
+		trace.WithAttributes(attribute.Int("song.id", int(id)), attribute.Bool("dry_run", dryRun)))
+	defer span.End()
+	s.log(ctx).Debug("starting refresh song",
+		zap.Uint("id", id),
+		zap.Bool("dry_run", dryRun))
+	song, err := s.repo.GetSong(ctx, id)
+	if err != nil {
+		s.log(ctx).Error("failed to load song for refresh",
+			zap.Uint("id", id),
+			zap.Error(err))
+		return nil, err
+	}
+
+	// dry run не должен менять кеш, поэтому кеш обходится, а не очищается
+	providerCtx := ctx
+	if dryRun {
+		providerCtx = withoutCache(ctx)
+	} else if s.cache != nil {
+		if _, err = s.cache.Delete(ctx, song.Group, song.Song); err != nil {
+			s.log(ctx).Warn("failed to invalidate song info cache", zap.Error(err))
+		}
+	}
+	info, err := s.provider.GetSongInfo(providerCtx, song.Group, song.Song)
+	if err != nil {
+		s.log(ctx).Error("failed to get song info for refresh",
+			zap.Uint("id", id),
+			zap.Error(err))
+		return nil, err
+	}
+
+	refreshed := *song
+	applySongInfo(&refreshed, info)
+	changes := diffSong(song, &refreshed)
+	s.log(ctx).Debug("song refresh diff",
+		zap.Uint("id", id),
+		zap.Int("changes", len(changes)))
+	if dryRun {
+		return changes, nil
+	}
+
+	now := time.Now()
+	refreshed.EnrichedAt = &now
+	refreshed.EnrichmentStatus = models.EnrichmentEnriched
+	refreshed.EnrichmentError = ""
+	err = s.repo.UpdateEnrichment(ctx, song, &refreshed)
+	if errors.Is(err, repository.ErrSongModified) {
+		// обновление собрано по версии песни, которую успели изменить: сохранять его нельзя,
+		// а повторять запрос к API ради этого не нужно — клиент или следующий фоновый проход повторят его сами
+		s.log(ctx).Warn("song changed during refresh, refresh discarded", zap.Uint("id", id))
+		return nil, err
+	}
+	if err != nil {
+		s.log(ctx).Error("failed to save refreshed song",
+			zap.Uint("id", id),
+			zap.Error(err))
+		return nil, err
+	}
+	s.songChanged(ctx, id, AuthorEnrichment, "refreshed from upstream")
+	s.log(ctx).Info("song refreshed successfully",
+		zap.Uint("id", id),
+		zap.Int("changes", len(changes)))
+	return changes, nil
+}
+
+// RefreshStaleSongs ставит в фоновую очередь обновление песен, обогащенных раньше чем olderThan назад,
+// и возвращает их количество
+func (s *SongService) RefreshStaleSongs(ctx context.Context, olderThan time.Duration) (int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.RefreshStaleSongs")
+	defer span.End()
+	s.log(ctx).Debug("starting refresh stale songs", zap.Duration("older_than", olderThan))
+	ids, err := s.repo.GetStaleSongIDs(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		s.log(ctx).Error("failed to get stale songs", zap.Error(err))
+		return 0, err
+	}
+	s.queue.EnqueueRefresh(ids)
+	s.log(ctx).Info("stale songs scheduled for refresh", zap.Int("count", len(ids)))
+	return len(ids), nil
+}
+
+// diffSong сравнивает обогащаемые поля песни до и после обновления
+func diffSong(old, updated *models.Song) []models.FieldChange {
+	changes := make([]models.FieldChange, 0, 3)
+	if !old.ReleaseDate.Equal(updated.ReleaseDate) {
+		changes = append(changes, models.FieldChange{
+			Field:  "release_date",
+			Old:    old.ReleaseDate.Format("02.01.2006"),
+			New:    updated.ReleaseDate.Format("02.01.2006"),
+			Source: updated.Sources.ReleaseDate,
+		})
+	}
+	if old.Text != updated.Text {
+		changes = append(changes, models.FieldChange{
+			Field:  "text",
+			Old:    old.Text,
+			New:    updated.Text,
+			Source: updated.Sources.Text,
+		})
+	}
+	if old.Link != updated.Link {
+		changes = append(changes, models.FieldChange{
+			Field:  "link",
+			Old:    old.Link,
+			New:    updated.Link,
+			Source: updated.Sources.Link,
+		})
+	}
+	return changes
+}
@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/repository"
+	"github.com/jaam8/online_song_library/pkg/fakeinfo"
+	"testing"
+	"time"
+)
+
+func TestRefreshSongDryRunKeepsCache(t *testing.T) {
+	env := newTestService(t, testOptions{cache: true})
+	s, fake, cache := env.s, env.fake, env.cache
+	ctx := context.Background()
+
+	fixture := testFixtures[0]
+	id, err := s.CreateSong(ctx, models.SongRaw{Group: fixture.Group, Song: fixture.Song})
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+
+	updated := fixture
+	updated.Link = "https://example.com/new"
+	fake.Add(updated)
+
+	changes, err := s.RefreshSong(ctx, id, true)
+	if err != nil {
+		t.Fatalf("dry run RefreshSong: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Field != "link" || changes[0].New != updated.Link {
+		t.Fatalf("dry run should report the live link change, got %+v", changes)
+	}
+	entry, ok, _ := cache.Get(ctx, fixture.Group, fixture.Song)
+	if !ok || entry.Info.Link != fixture.Link {
+		t.Fatalf("dry run must not change the cache, got %+v", entry)
+	}
+
+	if _, err = s.RefreshSong(ctx, id, false); err != nil {
+		t.Fatalf("RefreshSong: %v", err)
+	}
+	song, err := s.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong: %v", err)
+	}
+	if song.Link != updated.Link {
+		t.Errorf("link = %q, want %q", song.Link, updated.Link)
+	}
+	if song.EnrichmentAttempts != 1 {
+		t.Errorf("refresh must not count as enrichment attempt, got %d attempts", song.EnrichmentAttempts)
+	}
+	if entry, ok, _ = cache.Get(ctx, fixture.Group, fixture.Song); !ok || entry.Info.Link != updated.Link {
+		t.Errorf("refresh should store the new response in the cache, got %+v", entry)
+	}
+}
+
+func TestRefreshSongDiscardedAfterConcurrentUpdate(t *testing.T) {
+	env := newTestService(t, testOptions{fake: fakeinfo.Options{Latency: 100 * time.Millisecond}})
+	ctx := context.Background()
+
+	fixture := testFixtures[0]
+	id, err := env.s.CreateSong(ctx, models.SongRaw{Group: fixture.Group, Song: fixture.Song})
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+	updated := fixture
+	updated.Link = "https://example.com/new"
+	env.fake.Add(updated)
+
+	edited := models.SongRaw{
+		Group:       fixture.Group,
+		Song:        fixture.Song,
+		ReleaseDate: fixture.ReleaseDate,
+		Text:        "edited text",
+		Link:        "https://example.com/edited",
+	}
+	done := make(chan error, 1)
+	go func() {
+		// запрос к /info уже отправлен и ждет ответа
+		time.Sleep(30 * time.Millisecond)
+		done <- env.s.UpdateSong(ctx, id, edited)
+	}()
+	if _, err = env.s.RefreshSong(ctx, id, false); !errors.Is(err, repository.ErrSongModified) {
+		t.Fatalf("RefreshSong error = %v, want %v", err, repository.ErrSongModified)
+	}
+	if err = <-done; err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+
+	song, err := env.s.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong: %v", err)
+	}
+	if song.Text != edited.Text || song.Link != edited.Link {
+		t.Errorf("refresh overwrote the concurrent update: text %q, link %q", song.Text, song.Link)
+	}
+}
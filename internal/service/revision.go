@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// AuthorAnonymous автор изменений, сделанных через API без заголовка X-Author
+	AuthorAnonymous = "anonymous"
+	// AuthorEnrichment автор изменений из фонового обогащения и обновления песен
+	AuthorEnrichment = "enrichment"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+type revisionKey struct{}
+
+type revisionMeta struct {
+	author string
+	reason string
+}
+
+// WithRevision задает автора и причину изменений песен, сделанных с этим ctx; пустые значения
+// заменяются значениями по умолчанию для конкретного действия
+func WithRevision(ctx context.Context, author, reason string) context.Context {
+	return context.WithValue(ctx, revisionKey{}, revisionMeta{author: author, reason: reason})
+}
+
+// recordRevision сохраняет текущее состояние песни ревизией, если оно отличается от последней ревизии latest.
+// Ошибка только логируется: изменение песни уже сохранено
+func (s *SongService) recordRevision(ctx context.Context, song *models.Song, latest *models.SongRevision, author, reason string) {
+	if meta, ok := ctx.Value(revisionKey{}).(revisionMeta); ok {
+		if meta.author != "" {
+			author = meta.author
+		}
+		if meta.reason != "" {
+			reason = meta.reason
+		}
+	}
+
+	songID := song.ID
+	if latest != nil && latest.SameContent(song) {
+		s.log(ctx).Debug("song content unchanged, skipping revision", zap.Uint("id", songID))
+		return
+	}
+
+	revision := &models.SongRevision{
+		SongID:      songID,
+		Author:      author,
+		Reason:      reason,
+		Group:       song.Group,
+		Song:        song.Song,
+		ReleaseDate: song.ReleaseDate,
+		Text:        song.Text,
+		Link:        song.Link,
+	}
+	if err := s.repo.CreateRevision(ctx, revision); err != nil {
+		s.log(ctx).Error("failed to save revision", zap.Uint("id", songID), zap.Error(err))
+		return
+	}
+	s.log(ctx).Debug("revision saved",
+		zap.Uint("id", songID),
+		zap.Uint("revision", revision.ID),
+		zap.String("author", author))
+}
+
+// ListRevisions возвращает ревизии песни от новых к старым
+func (s *SongService) ListRevisions(ctx context.Context, songID uint, limit, offset int) ([]models.SongRevision, int64, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.ListRevisions",
+		trace.WithAttributes(attribute.Int("song.id", int(songID))))
+	defer span.End()
+	if _, err := s.repo.GetSong(ctx, songID); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.GetRevisions(ctx, songID, limit, offset)
+}
+
+func (s *SongService) GetRevision(ctx context.Context, songID, revisionID uint) (*models.SongRevision, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.GetRevision",
+		trace.WithAttributes(attribute.Int("song.id", int(songID)), attribute.Int("revision.id", int(revisionID))))
+	defer span.End()
+	if _, err := s.repo.GetSong(ctx, songID); err != nil {
+		return nil, err
+	}
+	return s.revision(ctx, songID, revisionID)
+}
+
+func (s *SongService) revision(ctx context.Context, songID, revisionID uint) (*models.SongRevision, error) {
+	revision, err := s.repo.GetRevision(ctx, songID, revisionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRevisionNotFound
+	}
+	return revision, err
+}
+
+// DiffRevisions сравнивает ревизии from и to; to == 0 означает последнюю ревизию
+func (s *SongService) DiffRevisions(ctx context.Context, songID, fromID, toID uint) (*models.RevisionDiff, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.DiffRevisions",
+		trace.WithAttributes(attribute.Int("song.id", int(songID))))
+	defer span.End()
+	if _, err := s.repo.GetSong(ctx, songID); err != nil {
+		return nil, err
+	}
+	from, err := s.revision(ctx, songID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	var to *models.SongRevision
+	if toID == 0 {
+		to, err = s.repo.GetLatestRevision(ctx, songID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = ErrRevisionNotFound
+		}
+	} else {
+		to, err = s.revision(ctx, songID, toID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.RevisionDiff{
+		From:   from.ID,
+		To:     to.ID,
+		Fields: make([]models.FieldChange, 0),
+		Lines:  lyrics.DiffLines(from.Text, to.Text),
+	}
+	for _, f := range []struct{ field, old, new string }{
+		{"group", from.Group, to.Group},
+		{"song", from.Song, to.Song},
+		{"release_date", from.ReleaseDate.Format("02.01.2006"), to.ReleaseDate.Format("02.01.2006")},
+		{"link", from.Link, to.Link},
+	} {
+		if f.old != f.new {
+			diff.Fields = append(diff.Fields, models.FieldChange{Field: f.field, Old: f.old, New: f.new, Source: to.Author})
+		}
+	}
+	return diff, nil
+}
+
+// RestoreRevision возвращает песне значения полей из ревизии и сохраняет результат новой ревизией.
+// Восстановленные поля считаются заданными вручную и не перезаписываются обновлением из внешнего API
+func (s *SongService) RestoreRevision(ctx context.Context, songID, revisionID uint) (*models.SongRevision, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.RestoreRevision",
+		trace.WithAttributes(attribute.Int("song.id", int(songID)), attribute.Int("revision.id", int(revisionID))))
+	defer span.End()
+	song, err := s.repo.GetSong(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := s.revision(ctx, songID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	song.Group = revision.Group
+	song.Song = revision.Song
+	song.ReleaseDate = revision.ReleaseDate
+	song.Text = revision.Text
+	song.Lyrics = lyrics.Structure(revision.Text)
+	song.Link = revision.Link
+	song.Sources = models.SongSources{}
+	if !song.ReleaseDate.IsZero() {
+		song.Sources.ReleaseDate = models.SourceManual
+	}
+	if song.Text != "" {
+		song.Sources.Text = models.SourceManual
+	}
+	if song.Link != "" {
+		song.Sources.Link = models.SourceManual
+	}
+	if err = s.repo.ReplaceSong(ctx, song); err != nil {
+		return nil, err
+	}
+	s.songChanged(ctx, songID, AuthorAnonymous, fmt.Sprintf("restored revision %d", revisionID))
+	s.log(ctx).Info("revision restored",
+		zap.Uint("id", songID),
+		zap.Uint("revision", revisionID))
+
+	latest, err := s.repo.GetLatestRevision(ctx, songID)
+	if err != nil {
+		return nil, err
+	}
+	return latest, nil
+}
@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/service"
+	"testing"
+)
+
+func TestRevisionsDiffAndRestore(t *testing.T) {
+	s := newTestService(t, testOptions{}).s
+	ctx := service.WithRevision(context.Background(), "alice", "")
+
+	raw := models.SongRaw{
+		Group:       "Muse",
+		Song:        "Uprising",
+		ReleaseDate: "07.09.2009",
+		Text:        "The paranoia is in bloom\nThe PR transmissions will resume",
+		Link:        "https://example.com/uprising",
+	}
+	id, err := s.CreateSong(ctx, raw)
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+
+	raw.Text = "The paranoia is in bloom\nThe PR transmissions will resume\nThey'll try to push drugs"
+	if err = s.UpdateSong(service.WithRevision(context.Background(), "bob", "add line"), id, raw); err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+	// повторное сохранение без изменений не создает ревизию
+	if err = s.UpdateSong(context.Background(), id, raw); err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+
+	revisions, total, err := s.ListRevisions(ctx, id, 10, 1)
+	if err != nil {
+		t.Fatalf("ListRevisions: %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("expected 2 revisions, got %d: %+v", total, revisions)
+	}
+	latest, first := revisions[0], revisions[1]
+	if first.Author != "alice" || first.Reason != "created" || latest.Author != "bob" || latest.Reason != "add line" {
+		t.Errorf("unexpected revision authors: %+v", revisions)
+	}
+
+	diff, err := s.DiffRevisions(ctx, id, first.ID, 0)
+	if err != nil {
+		t.Fatalf("DiffRevisions: %v", err)
+	}
+	if diff.To != latest.ID || len(diff.Fields) != 0 {
+		t.Errorf("unexpected diff header: %+v", diff)
+	}
+	last := diff.Lines[len(diff.Lines)-1]
+	if last.Op != lyrics.DiffInsert || last.Text != "They'll try to push drugs" || last.NewLine != 3 {
+		t.Errorf("unexpected last diff line: %+v", last)
+	}
+
+	restored, err := s.RestoreRevision(service.WithRevision(context.Background(), "carol", ""), id, first.ID)
+	if err != nil {
+		t.Fatalf("RestoreRevision: %v", err)
+	}
+	if restored.Author != "carol" || restored.Reason != "restored revision 1" ||
+		restored.Text != first.Text || !restored.ReleaseDate.Equal(first.ReleaseDate) {
+		t.Errorf("unexpected restored revision: %+v", restored)
+	}
+	song, err := s.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong: %v", err)
+	}
+	if song.Text != first.Text || song.Sources.Text != models.SourceManual {
+		t.Errorf("song was not restored: %q from %q", song.Text, song.Sources.Text)
+	}
+
+	if _, err = s.GetRevision(ctx, id, 99); !errors.Is(err, service.ErrRevisionNotFound) {
+		t.Errorf("expected ErrRevisionNotFound, got %v", err)
+	}
+}
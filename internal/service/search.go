@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/repository"
+	"github.com/jaam8/online_song_library/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"slices"
+)
+
+var ErrUnsupportedLanguage = errors.New("unsupported search language")
+
+type SearchConfig struct {
+	// Language конфигурация полнотекстового поиска по умолчанию: simple, english или russian
+	Language string `yaml:"SEARCH_LANGUAGE" env:"SEARCH_LANGUAGE" env-default:"simple"`
+}
+
+// SearchSongs ищет песни по названию и тексту; пустой language заменяется языком из конфигурации
+func (s *SongService) SearchSongs(ctx context.Context, query, language string, limit, offset int) ([]models.SongSearchHit, int64, error) {
+	if language == "" {
+		language = s.search.Language
+	}
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.SearchSongs",
+		trace.WithAttributes(attribute.String("search.language", language)))
+	defer span.End()
+	if !slices.Contains(repository.SearchLanguages, language) {
+		s.log(ctx).Debug("unsupported search language", zap.String("language", language))
+		return nil, 0, ErrUnsupportedLanguage
+	}
+
+	hits, totalCount, err := s.repo.SearchSongs(ctx, query, language, limit, offset)
+	if err != nil {
+		s.log(ctx).Error("failed to search songs", zap.Error(err))
+		return nil, 0, err
+	}
+	s.log(ctx).Debug("songs found",
+		zap.String("query", query),
+		zap.Int("count", len(hits)),
+		zap.Int64("total", totalCount))
+	return hits, totalCount, nil
+}
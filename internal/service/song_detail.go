@@ -0,0 +1,81 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jaam8/online_song_library/internal/models"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrBadUpstreamPayload = errors.New("bad upstream payload")
+
+// releaseDateLayouts форматы releaseDate, которые встречаются в ответах внешних API
+var releaseDateLayouts = []string{
+	"2.1.2006",
+	"2006-01-02",
+	time.RFC3339,
+	"2/1/2006",
+	"2 January 2006",
+	"January 2, 2006",
+}
+
+// songDetail ответ /info по схеме SongDetail из описания Music info API
+type songDetail struct {
+	ReleaseDate *string `json:"releaseDate"`
+	Text        *string `json:"text"`
+	Link        *string `json:"link"`
+}
+
+// decodeSongDetail разбирает и валидирует тело ответа /info.
+// Неизвестные поля и данные после объекта считаются нарушением схемы
+func decodeSongDetail(body []byte) (*models.SongInfo, error) {
+	var detail songDetail
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&detail); err != nil {
+		return nil, fmt.Errorf("%w: invalid json: %v", ErrBadUpstreamPayload, err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: unexpected data after json object", ErrBadUpstreamPayload)
+	}
+
+	var missing []string
+	if detail.ReleaseDate == nil || strings.TrimSpace(*detail.ReleaseDate) == "" {
+		missing = append(missing, "releaseDate")
+	}
+	if detail.Text == nil || strings.TrimSpace(*detail.Text) == "" {
+		missing = append(missing, "text")
+	}
+	if detail.Link == nil || strings.TrimSpace(*detail.Link) == "" {
+		missing = append(missing, "link")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing required fields %s", ErrBadUpstreamPayload, strings.Join(missing, ", "))
+	}
+
+	releaseDate, err := parseReleaseDate(*detail.ReleaseDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadUpstreamPayload, err)
+	}
+
+	return &models.SongInfo{
+		ReleaseDate: releaseDate,
+		Text:        *detail.Text,
+		Link:        strings.TrimSpace(*detail.Link),
+	}, nil
+}
+
+// parseReleaseDate пробует разобрать дату во всех поддерживаемых форматах
+func parseReleaseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range releaseDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported releaseDate format %q", value)
+}
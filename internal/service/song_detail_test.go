@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeSongDetail(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "valid", body: `{"releaseDate": "16.07.2006", "text": "Ooh baby", "link": "https://example.com"}` + "\n"},
+		{name: "missing field", body: `{"releaseDate": "16.07.2006", "text": "Ooh baby"}`, wantErr: true},
+		{name: "unknown field", body: `{"releaseDate": "16.07.2006", "text": "Ooh baby", "link": "https://example.com", "album": "Black Holes"}`, wantErr: true},
+		{name: "trailing object", body: `{"releaseDate": "16.07.2006", "text": "Ooh baby", "link": "https://example.com"}{"text": "other"}`, wantErr: true},
+		{name: "trailing garbage", body: `{"releaseDate": "16.07.2006", "text": "Ooh baby", "link": "https://example.com"} }`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := decodeSongDetail([]byte(tt.body))
+			if tt.wantErr {
+				if !errors.Is(err, ErrBadUpstreamPayload) {
+					t.Fatalf("error = %v, want %v", err, ErrBadUpstreamPayload)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeSongDetail: %v", err)
+			}
+			if info.Text != "Ooh baby" || info.Link != "https://example.com" || info.ReleaseDate.Year() != 2006 {
+				t.Errorf("unexpected song info %+v", info)
+			}
+		})
+	}
+}
@@ -1,148 +1,367 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
+	"github.com/jaam8/online_song_library/internal/lyrics"
 	"github.com/jaam8/online_song_library/internal/models"
 	"github.com/jaam8/online_song_library/internal/repository"
+	"github.com/jaam8/online_song_library/internal/tracing"
+	"github.com/jaam8/online_song_library/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
-	"io"
-	"net/http"
-	"net/url"
 	"time"
 )
 
 var ErrParsingTime = errors.New("error parsing time")
 
 type SongService struct {
-	repo       *repository.SongRepository
-	l          *zap.Logger
-	swaggerUrl string
+	repo     repository.Store
+	provider SongInfoProvider
+	cache    SongInfoCache
+	queue    *EnrichmentQueue
+	search   SearchConfig
+	l        *zap.Logger
 }
 
-func New(repo *repository.SongRepository, log *zap.Logger, swaggerUrl string) *SongService {
-	return &SongService{repo: repo, l: log, swaggerUrl: swaggerUrl}
+// New создает сервис песен; cache может быть nil, если кеширование ответов API отключено
+func New(repo repository.Store, provider SongInfoProvider, cache SongInfoCache,
+	queue *EnrichmentQueue, search SearchConfig, log *zap.Logger) *SongService {
+	return &SongService{repo: repo, provider: provider, cache: cache, queue: queue, search: search, l: log}
 }
 
-func (s *SongService) CreateSong(group, songName string) (uint, error) {
-	s.l.Debug("starting create song",
-		zap.String("group", group),
-		zap.String("song", songName))
+// log возвращает логгер запроса из ctx, а если его нет — логгер сервиса
+func (s *SongService) log(ctx context.Context) *zap.Logger {
+	return logger.FromContextOr(ctx, s.l)
+}
 
-	var songRaw models.SongRaw
-	params := url.Values{}
-	params.Add("group", group)
-	params.Add("song", songName)
-	reqURL := fmt.Sprintf("%s?%s", s.swaggerUrl, params.Encode())
-	s.l.Debug("sending request to swagger", zap.String("url", reqURL))
+// CreateSong сохраняет песню; незаполненные release_date, text и link запрашиваются у провайдера
+func (s *SongService) CreateSong(ctx context.Context, songRaw models.SongRaw) (uint, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.CreateSong",
+		trace.WithAttributes(attribute.String("song.group", songRaw.Group), attribute.String("song.name", songRaw.Song)))
+	defer span.End()
+	s.log(ctx).Debug("starting create song",
+		zap.String("group", songRaw.Group),
+		zap.String("song", songRaw.Song))
 
-	resp, err := http.Get(reqURL)
+	song, err := s.newSong(ctx, songRaw)
 	if err != nil {
-		s.l.Error("http get failed", zap.Error(err))
 		return 0, err
 	}
-	defer resp.Body.Close()
+	song.EnrichmentStatus = models.EnrichmentEnriched
+
+	if !songRaw.HasDetails() {
+		info, err := s.provider.GetSongInfo(ctx, song.Group, song.Song)
+		if err = fillSongInfo(song, info, err); err != nil {
+			s.log(ctx).Error("failed to get song info", zap.Error(err))
+			return 0, err
+		}
+		now := time.Now()
+		song.EnrichedAt = &now
+		song.EnrichmentAttempts = 1
+	}
+	s.log(ctx).Debug("creating song entity",
+		zap.String("group", song.Group),
+		zap.String("song", song.Song),
+		zap.Time("releaseDate", song.ReleaseDate),
+		zap.Any("sources", song.Sources))
 
-	body, err := io.ReadAll(resp.Body)
+	id, err := s.repo.CreateSong(ctx, song)
 	if err != nil {
-		s.l.Error("failed to read swagger response", zap.Error(err))
+		s.log(ctx).Error("create song failed", zap.Error(err))
 		return 0, err
 	}
-	s.l.Debug("received response from swagger",
-		zap.Int("status", resp.StatusCode),
-		zap.String("body", string(body)))
+	s.songChanged(ctx, id, AuthorAnonymous, "created")
+	s.log(ctx).Info("song created successfully", zap.Uint("id", id))
+	return id, nil
+}
+
+// CreateSongAsync сохраняет песню в состоянии pending и ставит ее обогащение в очередь
+func (s *SongService) CreateSongAsync(ctx context.Context, songRaw models.SongRaw) (uint, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.CreateSongAsync",
+		trace.WithAttributes(attribute.String("song.group", songRaw.Group), attribute.String("song.name", songRaw.Song)))
+	defer span.End()
+	s.log(ctx).Debug("starting async create song",
+		zap.String("group", songRaw.Group),
+		zap.String("song", songRaw.Song))
 
-	if resp.StatusCode != http.StatusOK {
-		s.l.Error("swagger response not ok",
-			zap.Int("status", resp.StatusCode))
-		return 0, fmt.Errorf("swagger error: status %d", resp.StatusCode)
+	song, err := s.newSong(ctx, songRaw)
+	if err != nil {
+		return 0, err
 	}
+	song.EnrichmentStatus = models.EnrichmentPending
 
-	if err = json.Unmarshal(body, &songRaw); err != nil {
-		s.l.Error("failed to unmarshal swagger response", zap.Error(err))
+	id, err := s.repo.CreateSong(ctx, song)
+	if err != nil {
+		s.log(ctx).Error("create song failed", zap.Error(err))
 		return 0, err
 	}
-	s.l.Debug("unmarshaled swagger response",
-		zap.String("releaseDate", songRaw.ReleaseDate))
+	if !s.queue.Enqueue(id) {
+		s.queue.Retry(id)
+	}
+	s.songChanged(ctx, id, AuthorAnonymous, "created")
+	s.log(ctx).Info("song created, enrichment pending", zap.Uint("id", id))
+	return id, nil
+}
+
+// newSong собирает песню из запроса, помечая заданные вручную поля источником manual
+func (s *SongService) newSong(ctx context.Context, songRaw models.SongRaw) (*models.Song, error) {
+	song := &models.Song{
+		Group: songRaw.Group,
+		Song:  songRaw.Song,
+		Text:  songRaw.Text,
+		Link:  songRaw.Link,
+	}
+	if songRaw.ReleaseDate != "" {
+		releaseDate, err := time.Parse("02.01.2006", songRaw.ReleaseDate)
+		if err != nil {
+			s.log(ctx).Error("failed to parse release_date",
+				zap.String("release_date", songRaw.ReleaseDate),
+				zap.Error(err))
+			return nil, ErrParsingTime
+		}
+		song.ReleaseDate = releaseDate
+		song.Sources.ReleaseDate = models.SourceManual
+	}
+	if song.Text != "" {
+		song.Sources.Text = models.SourceManual
+		song.Lyrics = lyrics.Structure(song.Text)
+	}
+	if song.Link != "" {
+		song.Sources.Link = models.SourceManual
+	}
+	return song, nil
+}
+
+// applySongInfo заполняет данными провайдера поля песни, не заданные вручную
+func applySongInfo(song *models.Song, info *models.SongInfo) {
+	if song.Sources.ReleaseDate != models.SourceManual && !info.ReleaseDate.IsZero() {
+		song.ReleaseDate = info.ReleaseDate
+		song.Sources.ReleaseDate = sourceName(info.Sources.ReleaseDate, models.SourceUpstream)
+	}
+	if song.Sources.Text != models.SourceManual && info.Text != "" {
+		song.Text = info.Text
+		song.Lyrics = lyrics.Structure(info.Text)
+		song.Sources.Text = sourceName(info.Sources.Text, models.SourceUpstream)
+	}
+	if song.Sources.Link != models.SourceManual && info.Link != "" {
+		song.Link = info.Link
+		song.Sources.Link = sourceName(info.Sources.Link, models.SourceUpstream)
+	}
+}
+
+// fillSongInfo дополняет песню ответом провайдера. Если и после этого не хватает полей,
+// песня не меняется, а возвращается ошибка провайдера или ErrSongInfoNotFound
+func fillSongInfo(song *models.Song, info *models.SongInfo, err error) error {
+	filled := *song
+	if info != nil {
+		applySongInfo(&filled, info)
+	}
+	if filled.ReleaseDate.IsZero() || filled.Text == "" || filled.Link == "" {
+		if err != nil {
+			return err
+		}
+		return ErrSongInfoNotFound
+	}
+	*song = filled
+	return nil
+}
+
+// StartEnrichment запускает воркеры обогащения и возвращает в очередь песни, оставшиеся в состоянии pending
+func (s *SongService) StartEnrichment(ctx context.Context) error {
+	s.queue.Start(ctx, s.handleJob)
+
+	ids, err := s.repo.GetSongIDsByEnrichmentStatus(ctx, models.EnrichmentPending)
+	if err != nil {
+		s.log(ctx).Error("failed to load pending songs", zap.Error(err))
+		return err
+	}
+	s.queue.EnqueuePending(ids)
+	s.log(ctx).Info("pending songs enqueued", zap.Int("count", len(ids)))
+	return nil
+}
+
+func (s *SongService) handleJob(ctx context.Context, job enrichmentJob) {
+	switch job.kind {
+	case jobRefresh:
+		if _, err := s.RefreshSong(ctx, job.id, false); err != nil {
+			s.log(ctx).Error("background refresh failed",
+				zap.Uint("id", job.id),
+				zap.Error(err))
+		}
+	default:
+		s.enrichSong(ctx, job.id)
+	}
+}
 
-	releaseDate, err := time.Parse("02.01.2006", songRaw.ReleaseDate)
+// enrichSong выполняет одну попытку обогащения песни, при временной ошибке повторяет ее позже
+func (s *SongService) enrichSong(ctx context.Context, id uint) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.enrichSong",
+		trace.WithAttributes(attribute.Int("song.id", int(id))))
+	defer span.End()
+	s.log(ctx).Debug("starting enrich song", zap.Uint("id", id))
+	song, err := s.repo.GetSong(ctx, id)
 	if err != nil {
-		s.l.Error("failed to parse release_date",
-			zap.String("releaseDate", songRaw.ReleaseDate),
+		s.log(ctx).Error("failed to load song for enrichment",
+			zap.Uint("id", id),
 			zap.Error(err))
-		return 0, err
+		return
+	}
+	if song.EnrichmentStatus != models.EnrichmentPending {
+		s.log(ctx).Debug("song is not pending, skipping enrichment",
+			zap.Uint("id", id),
+			zap.String("status", string(song.EnrichmentStatus)))
+		return
 	}
 
-	song := models.Song{
-		Group:       group,
-		Song:        songName,
-		ReleaseDate: releaseDate,
-		Text:        songRaw.Text,
-		Link:        songRaw.Link,
+	read := *song
+	song.EnrichmentAttempts++
+	info, err := s.provider.GetSongInfo(ctx, song.Group, song.Song)
+	if errors.Is(err, context.Canceled) {
+		s.log(ctx).Warn("song enrichment interrupted", zap.Uint("id", id))
+		return
+	}
+	err = fillSongInfo(song, info, err)
+	switch {
+	case err == nil:
+		now := time.Now()
+		song.EnrichedAt = &now
+		song.EnrichmentStatus = models.EnrichmentEnriched
+		song.EnrichmentError = ""
+	case errors.Is(err, ErrSongInfoNotFound) || s.queue.Exhausted(song.EnrichmentAttempts):
+		song.EnrichmentStatus = models.EnrichmentFailed
+		song.EnrichmentError = err.Error()
+	default:
+		song.EnrichmentError = err.Error()
 	}
-	s.l.Debug("creating song entity",
-		zap.String("group", song.Group),
-		zap.String("song", song.Song),
-		zap.Time("releaseDate", song.ReleaseDate))
 
-	id, err := s.repo.CreateSong(&song)
+	err = s.repo.UpdateEnrichment(ctx, &read, song)
+	if errors.Is(err, repository.ErrSongModified) {
+		// песню изменили, пока шел запрос к провайдеру: результат собран по устаревшей версии,
+		// поэтому он отбрасывается, а обогащение повторяется по актуальной
+		s.log(ctx).Warn("song changed during enrichment, will retry", zap.Uint("id", id))
+		s.queue.Retry(id)
+		return
+	}
 	if err != nil {
-		s.l.Error("create song failed", zap.Error(err))
-		return 0, err
+		s.log(ctx).Error("failed to save enrichment result",
+			zap.Uint("id", id),
+			zap.Error(err))
+		return
+	}
+	switch song.EnrichmentStatus {
+	case models.EnrichmentPending:
+		s.log(ctx).Warn("song enrichment failed, will retry",
+			zap.Uint("id", id),
+			zap.Int("attempts", song.EnrichmentAttempts),
+			zap.String("error", song.EnrichmentError))
+		s.queue.Retry(id)
+	case models.EnrichmentFailed:
+		s.log(ctx).Warn("song enrichment failed",
+			zap.Uint("id", id),
+			zap.Int("attempts", song.EnrichmentAttempts),
+			zap.String("error", song.EnrichmentError))
+	default:
+		s.songChanged(ctx, id, AuthorEnrichment, "enriched from upstream")
+		s.log(ctx).Info("song enriched successfully", zap.Uint("id", id))
 	}
-	s.l.Info("song created successfully", zap.Uint("id", id))
-	return id, nil
 }
 
-func (s *SongService) GetSong(id uint) (*models.Song, error) {
-	s.l.Debug("retrieving song", zap.Uint("id", id))
-	song, err := s.repo.GetSong(id)
+func (s *SongService) GetSong(ctx context.Context, id uint) (*models.Song, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.GetSong",
+		trace.WithAttributes(attribute.Int("song.id", int(id))))
+	defer span.End()
+	s.log(ctx).Debug("retrieving song", zap.Uint("id", id))
+	song, err := s.repo.GetSong(ctx, id)
 	if err != nil {
-		s.l.Error("failed to retrieve song",
+		s.log(ctx).Error("failed to retrieve song",
 			zap.Uint("id", id),
 			zap.Error(err))
 	} else {
-		s.l.Debug("song retrieved", zap.Uint("id", song.ID))
+		s.log(ctx).Debug("song retrieved", zap.Uint("id", song.ID))
 	}
 	return song, err
 }
 
-func (s *SongService) GetAllSong(limit, offset int, filters map[string]interface{}) ([]models.Song, int64, error) {
-	s.l.Debug("retrieving all songs",
+func (s *SongService) GetAllSong(ctx context.Context, limit, offset int, filters []models.SongFilter, sort []models.SortField) ([]models.Song, int64, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.GetAllSong")
+	defer span.End()
+	s.log(ctx).Debug("retrieving all songs",
 		zap.Int("limit", limit),
 		zap.Int("offset", offset),
-		zap.Any("filters", filters))
-	if val, ok := filters["release_date"]; ok {
-		releaseDate, err := time.Parse("02.01.2006", val.(string))
-		if err != nil {
-			s.l.Error("failed to parse release_date",
-				zap.String("release_date", val.(string)),
-				zap.Error(err))
-			return nil, 0, ErrParsingTime
-		}
-		filters["release_date"] = releaseDate
+		zap.Any("filters", filters),
+		zap.Any("sort", sort))
+	if err := s.parseDateFilters(ctx, filters); err != nil {
+		return nil, 0, err
 	}
-	songs, totalCount, err := s.repo.GetAllSongs(limit, offset, filters)
+	songs, totalCount, err := s.repo.GetAllSongs(ctx, limit, offset, filters, sort)
 	if err != nil {
-		s.l.Error("failed to retrieve songs", zap.Error(err))
+		s.log(ctx).Error("failed to retrieve songs", zap.Error(err))
 	} else {
-		s.l.Debug("retrieved songs",
+		s.log(ctx).Debug("retrieved songs",
 			zap.Int("count", len(songs)),
 			zap.Int64("total", totalCount))
 	}
 	return songs, totalCount, err
 }
 
-func (s *SongService) UpdateSong(id uint, updatedSong models.SongRaw) error {
-	s.l.Debug("starting update song", zap.Uint("id", id))
+// GetSongsPage возвращает страницу песен по курсору; пустой cursor — первая страница
+func (s *SongService) GetSongsPage(ctx context.Context, filters []models.SongFilter, sort []models.SortField, cursor string, limit int, withTotal bool) (*models.SongPage, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.GetSongsPage")
+	defer span.End()
+	s.log(ctx).Debug("retrieving songs page",
+		zap.Int("limit", limit),
+		zap.Bool("with_total", withTotal),
+		zap.Any("filters", filters),
+		zap.Any("sort", sort))
+	if err := s.parseDateFilters(ctx, filters); err != nil {
+		return nil, err
+	}
+	page, err := s.repo.GetSongsPage(ctx, filters, sort, cursor, limit, withTotal)
+	if err != nil {
+		s.log(ctx).Error("failed to retrieve songs page", zap.Error(err))
+		return nil, err
+	}
+	s.log(ctx).Debug("retrieved songs page", zap.Int("count", len(page.Songs)))
+	return page, nil
+}
+
+// parseDateFilters переводит значения фильтров по release_date из формата 02.01.2006 в time.Time
+func (s *SongService) parseDateFilters(ctx context.Context, filters []models.SongFilter) error {
+	for i, f := range filters {
+		if f.Field != "release_date" {
+			continue
+		}
+		values := make([]interface{}, 0, len(f.Values))
+		for _, val := range f.Values {
+			raw, _ := val.(string)
+			releaseDate, err := time.Parse("02.01.2006", raw)
+			if err != nil {
+				s.log(ctx).Error("failed to parse release_date",
+					zap.String("release_date", raw),
+					zap.Error(err))
+				return ErrParsingTime
+			}
+			values = append(values, releaseDate)
+		}
+		filters[i].Values = values
+	}
+	return nil
+}
+
+func (s *SongService) UpdateSong(ctx context.Context, id uint, updatedSong models.SongRaw) error {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.UpdateSong",
+		trace.WithAttributes(attribute.Int("song.id", int(id))))
+	defer span.End()
+	s.log(ctx).Debug("starting update song", zap.Uint("id", id))
 	releaseDate, err := time.Parse("02.01.2006", updatedSong.ReleaseDate)
 	if err != nil {
-		s.l.Error("failed to parse release_date",
+		s.log(ctx).Error("failed to parse release_date",
 			zap.String("release_date", updatedSong.ReleaseDate),
 			zap.Error(err))
-		return err
+		return ErrParsingTime
 	}
 	song := models.Song{
 		Group:       updatedSong.Group,
@@ -150,39 +369,80 @@ func (s *SongService) UpdateSong(id uint, updatedSong models.SongRaw) error {
 		ReleaseDate: releaseDate,
 		Text:        updatedSong.Text,
 		Link:        updatedSong.Link,
+		Sources: models.SongSources{
+			ReleaseDate: models.SourceManual,
+			Text:        models.SourceManual,
+			Link:        models.SourceManual,
+		},
 	}
-	s.l.Debug("updating song entity",
+	if song.Text != "" {
+		song.Lyrics = lyrics.Structure(song.Text)
+	}
+	s.log(ctx).Debug("updating song entity",
 		zap.String("group", song.Group),
 		zap.String("song", song.Song),
 		zap.Time("releaseDate", song.ReleaseDate))
-	err = s.repo.UpdateSong(id, song)
+	err = s.repo.UpdateSong(ctx, id, song)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			s.l.Warn("song not found", zap.Uint("id", id))
+			s.log(ctx).Warn("song not found", zap.Uint("id", id))
 		} else {
-			s.l.Error("update song failed",
+			s.log(ctx).Error("update song failed",
 				zap.Uint("id", id),
 				zap.Error(err))
 		}
 		return err
 	}
-	s.l.Info("song updated successfully", zap.Uint("id", id))
+	s.songChanged(ctx, id, AuthorAnonymous, "updated")
+	s.log(ctx).Info("song updated successfully", zap.Uint("id", id))
 	return nil
 }
 
-func (s *SongService) DeleteSong(id uint) error {
-	s.l.Debug("starting delete song", zap.Uint("id", id))
-	err := s.repo.DeleteSong(id)
+func (s *SongService) DeleteSong(ctx context.Context, id uint) error {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.DeleteSong",
+		trace.WithAttributes(attribute.Int("song.id", int(id))))
+	defer span.End()
+	s.log(ctx).Debug("starting delete song", zap.Uint("id", id))
+	err := s.repo.DeleteSong(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			s.l.Warn("song not found", zap.Uint("id", id))
+			s.log(ctx).Warn("song not found", zap.Uint("id", id))
 		} else {
-			s.l.Error("delete song failed",
+			s.log(ctx).Error("delete song failed",
 				zap.Uint("id", id),
 				zap.Error(err))
 		}
 		return err
 	}
-	s.l.Info("song deleted successfully", zap.Uint("id", id))
+	s.log(ctx).Info("song deleted successfully", zap.Uint("id", id))
 	return nil
 }
+
+// PurgeSongInfoCache удаляет из кеша ответ API для указанной песни, а если group и song пустые — весь кеш
+func (s *SongService) PurgeSongInfoCache(ctx context.Context, group, songName string) (int64, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.PurgeSongInfoCache")
+	defer span.End()
+	s.log(ctx).Debug("starting purge song info cache",
+		zap.String("group", group),
+		zap.String("song", songName))
+	if s.cache == nil {
+		s.log(ctx).Debug("song info cache is disabled")
+		return 0, nil
+	}
+
+	var (
+		purged int64
+		err    error
+	)
+	if group == "" && songName == "" {
+		purged, err = s.cache.Purge(ctx)
+	} else {
+		purged, err = s.cache.Delete(ctx, group, songName)
+	}
+	if err != nil {
+		s.log(ctx).Error("purge song info cache failed", zap.Error(err))
+		return 0, err
+	}
+	s.log(ctx).Info("song info cache purged", zap.Int64("purged", purged))
+	return purged, nil
+}
@@ -0,0 +1,196 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/service"
+	"github.com/jaam8/online_song_library/pkg/fakeinfo"
+	"testing"
+	"time"
+)
+
+func TestCreateSongEnrichesFromUpstream(t *testing.T) {
+	s := newTestService(t, testOptions{}).s
+	ctx := context.Background()
+
+	id, err := s.CreateSong(ctx, models.SongRaw{Group: "Muse", Song: "Supermassive Black Hole"})
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+	song, err := s.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong: %v", err)
+	}
+
+	detail := testFixtures[0].SongDetail
+	if got := song.ReleaseDate.Format("02.01.2006"); got != detail.ReleaseDate {
+		t.Errorf("release_date = %s, want %s", got, detail.ReleaseDate)
+	}
+	if song.Text != detail.Text {
+		t.Errorf("text = %q, want %q", song.Text, detail.Text)
+	}
+	if song.Link != detail.Link {
+		t.Errorf("link = %q, want %q", song.Link, detail.Link)
+	}
+	if song.EnrichmentStatus != models.EnrichmentEnriched {
+		t.Errorf("enrichment_status = %s, want %s", song.EnrichmentStatus, models.EnrichmentEnriched)
+	}
+	if song.Sources.Text == models.SourceManual || song.Sources.Text == "" {
+		t.Errorf("text source = %q, want upstream provider", song.Sources.Text)
+	}
+	if song.Lyrics == nil || len(song.Lyrics.Sections) != 2 {
+		t.Errorf("expected structured lyrics with 2 sections, got %+v", song.Lyrics)
+	}
+
+	revisions, total, err := s.ListRevisions(ctx, id, 10, 1)
+	if err != nil {
+		t.Fatalf("ListRevisions: %v", err)
+	}
+	if total != 1 || revisions[0].Reason != "created" {
+		t.Errorf("expected one created revision, got %d: %+v", total, revisions)
+	}
+}
+
+func TestCreateSongKeepsManualFields(t *testing.T) {
+	s := newTestService(t, testOptions{}).s
+	ctx := context.Background()
+
+	id, err := s.CreateSong(ctx, models.SongRaw{
+		Group: "Muse",
+		Song:  "Supermassive Black Hole",
+		Text:  "my own lyrics",
+	})
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+	song, err := s.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong: %v", err)
+	}
+	if song.Text != "my own lyrics" || song.Sources.Text != models.SourceManual {
+		t.Errorf("manual text was overwritten: %q from %q", song.Text, song.Sources.Text)
+	}
+	if song.Link != testFixtures[0].Link {
+		t.Errorf("link = %q, want %q", song.Link, testFixtures[0].Link)
+	}
+}
+
+func TestCreateSongUpstreamErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts fakeinfo.Options
+		song string
+		want error
+	}{
+		{name: "unknown song", song: "Unknown", want: service.ErrSongInfoNotFound},
+		{name: "server error", opts: fakeinfo.Options{Mode: fakeinfo.ModeError}, song: "Supermassive Black Hole", want: service.ErrUpstreamFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestService(t, testOptions{fake: tt.opts})
+			s, repo := env.s, env.repo
+			ctx := context.Background()
+
+			_, err := s.CreateSong(ctx, models.SongRaw{Group: "Muse", Song: tt.song})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("CreateSong error = %v, want %v", err, tt.want)
+			}
+			if _, total, _ := repo.GetAllSongs(ctx, 10, 1, nil, nil); total != 0 {
+				t.Errorf("song must not be saved on error, got %d songs", total)
+			}
+		})
+	}
+}
+
+func TestEnrichmentKeepsConcurrentUpdate(t *testing.T) {
+	env := newTestService(t, testOptions{fake: fakeinfo.Options{Latency: 100 * time.Millisecond}, workers: 1})
+	ctx := context.Background()
+
+	id, err := env.s.CreateSongAsync(ctx, models.SongRaw{Group: "Muse", Song: "Supermassive Black Hole"})
+	if err != nil {
+		t.Fatalf("CreateSongAsync: %v", err)
+	}
+	// запрос к /info уже отправлен и ждет ответа
+	time.Sleep(30 * time.Millisecond)
+	edited := models.SongRaw{
+		Group:       "Muse",
+		Song:        "Supermassive Black Hole",
+		ReleaseDate: "01.01.2000",
+		Text:        "edited text",
+		Link:        "https://example.com/edited",
+	}
+	if err = env.s.UpdateSong(ctx, id, edited); err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+
+	var song *models.Song
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if song, err = env.s.GetSong(ctx, id); err != nil {
+			t.Fatalf("GetSong: %v", err)
+		}
+		if song.EnrichmentStatus != models.EnrichmentPending {
+			break
+		}
+	}
+	if song.EnrichmentStatus != models.EnrichmentEnriched {
+		t.Fatalf("enrichment_status = %s, want %s", song.EnrichmentStatus, models.EnrichmentEnriched)
+	}
+	if song.Text != edited.Text || song.Link != edited.Link {
+		t.Errorf("enrichment overwrote the concurrent update: text %q, link %q", song.Text, song.Link)
+	}
+}
+
+func TestUpdateSongRejectsInvalidReleaseDate(t *testing.T) {
+	s := newTestService(t, testOptions{}).s
+	ctx := context.Background()
+
+	id, err := s.CreateSong(ctx, models.SongRaw{Group: "Muse", Song: "Supermassive Black Hole"})
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+	err = s.UpdateSong(ctx, id, models.SongRaw{
+		Group:       "Muse",
+		Song:        "Supermassive Black Hole",
+		ReleaseDate: "2006-07-16",
+		Text:        "text",
+		Link:        "https://example.com",
+	})
+	if !errors.Is(err, service.ErrParsingTime) {
+		t.Fatalf("UpdateSong error = %v, want %v", err, service.ErrParsingTime)
+	}
+}
+
+func TestCreateSongFromPartialFixture(t *testing.T) {
+	partial := []fakeinfo.Fixture{{
+		Group:      "Muse",
+		Song:       "Uprising",
+		SongDetail: fakeinfo.SongDetail{Text: "Paranoia is in bloom"},
+	}}
+	s := newTestService(t, testOptions{fixtures: partial}).s
+	ctx := context.Background()
+
+	if _, err := s.CreateSong(ctx, models.SongRaw{Group: "Muse", Song: "Uprising"}); !errors.Is(err, service.ErrSongInfoNotFound) {
+		t.Fatalf("CreateSong without manual fields error = %v, want %v", err, service.ErrSongInfoNotFound)
+	}
+
+	id, err := s.CreateSong(ctx, models.SongRaw{
+		Group:       "Muse",
+		Song:        "Uprising",
+		ReleaseDate: "07.09.2009",
+		Link:        "https://example.com/uprising",
+	})
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+	song, err := s.GetSong(ctx, id)
+	if err != nil {
+		t.Fatalf("GetSong: %v", err)
+	}
+	if song.Text != partial[0].Text || song.Sources.Text != service.ProviderFixtures {
+		t.Errorf("text = %q from %q, want fixture text", song.Text, song.Sources.Text)
+	}
+	if song.Link != "https://example.com/uprising" || song.Sources.Link != models.SourceManual {
+		t.Errorf("manual link was overwritten: %q from %q", song.Link, song.Sources.Link)
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jaam8/online_song_library/internal/lyrics"
+	"github.com/jaam8/online_song_library/internal/models"
+	"github.com/jaam8/online_song_library/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
+	"strings"
+)
+
+var (
+	ErrInvalidLanguage     = errors.New("invalid language tag")
+	ErrEmptyTranslation    = errors.New("translation text is empty")
+	ErrStanzaMismatch      = errors.New("translation stanzas do not match the original")
+	ErrTranslationNotFound = errors.New("translation not found")
+)
+
+// NormalizeLanguage приводит тег языка BCP 47 к каноническому виду: en-us -> en-US
+func NormalizeLanguage(raw string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(raw))
+	if err != nil || tag == language.Und {
+		return "", ErrInvalidLanguage
+	}
+	return tag.String(), nil
+}
+
+// ListTranslations возвращает переводы песни; для несуществующей песни — gorm.ErrRecordNotFound
+func (s *SongService) ListTranslations(ctx context.Context, songID uint) ([]models.SongTranslation, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.ListTranslations",
+		trace.WithAttributes(attribute.Int("song.id", int(songID))))
+	defer span.End()
+	if _, err := s.repo.GetSong(ctx, songID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTranslations(ctx, songID)
+}
+
+func (s *SongService) GetTranslation(ctx context.Context, songID uint, lang string) (*models.SongTranslation, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.GetTranslation",
+		trace.WithAttributes(attribute.Int("song.id", int(songID)), attribute.String("language", lang)))
+	defer span.End()
+	lang, err := NormalizeLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.repo.GetSong(ctx, songID); err != nil {
+		return nil, err
+	}
+	return s.translation(ctx, songID, lang)
+}
+
+func (s *SongService) translation(ctx context.Context, songID uint, lang string) (*models.SongTranslation, error) {
+	t, err := s.repo.GetTranslation(ctx, songID, lang)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTranslationNotFound
+	}
+	return t, err
+}
+
+// PutTranslation создает или заменяет перевод и сообщает, был ли он создан. Чтобы куплеты перевода
+// и оригинала совпадали по номерам, их число должно быть одинаковым
+func (s *SongService) PutTranslation(ctx context.Context, songID uint, lang, text string) (*models.SongTranslation, bool, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.PutTranslation",
+		trace.WithAttributes(attribute.Int("song.id", int(songID)), attribute.String("language", lang)))
+	defer span.End()
+	lang, err := NormalizeLanguage(lang)
+	if err != nil {
+		return nil, false, err
+	}
+	stanzas := lyrics.Parse(text)
+	if len(stanzas) == 0 {
+		return nil, false, ErrEmptyTranslation
+	}
+	song, err := s.repo.GetSong(ctx, songID)
+	if err != nil {
+		return nil, false, err
+	}
+	if original := lyrics.Parse(song.Text); len(original) > 0 && len(original) != len(stanzas) {
+		s.log(ctx).Debug("translation stanzas do not match",
+			zap.Uint("song_id", songID),
+			zap.Int("translation", len(stanzas)),
+			zap.Int("original", len(original)))
+		return nil, false, fmt.Errorf("%w: translation has %d stanzas, original has %d",
+			ErrStanzaMismatch, len(stanzas), len(original))
+	}
+
+	translation := &models.SongTranslation{SongID: songID, Language: lang, Text: lyrics.Normalize(text)}
+	created, err := s.repo.SaveTranslation(ctx, translation)
+	if err != nil {
+		return nil, false, err
+	}
+	s.log(ctx).Info("translation saved",
+		zap.Uint("song_id", songID),
+		zap.String("language", lang),
+		zap.Bool("created", created))
+	return translation, created, nil
+}
+
+func (s *SongService) DeleteTranslation(ctx context.Context, songID uint, lang string) error {
+	ctx, span := tracing.Tracer().Start(ctx, "SongService.DeleteTranslation",
+		trace.WithAttributes(attribute.Int("song.id", int(songID)), attribute.String("language", lang)))
+	defer span.End()
+	lang, err := NormalizeLanguage(lang)
+	if err != nil {
+		return err
+	}
+	if _, err = s.repo.GetSong(ctx, songID); err != nil {
+		return err
+	}
+	err = s.repo.DeleteTranslation(ctx, songID, lang)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTranslationNotFound
+	}
+	if err == nil {
+		s.log(ctx).Info("translation deleted",
+			zap.Uint("song_id", songID),
+			zap.String("language", lang))
+	}
+	return err
+}
+
+// NegotiateTranslation выбирает перевод по предпочтениям клиента из Accept-Language.
+// Возвращает nil, если ни один перевод не подходит лучше оригинала
+func (s *SongService) NegotiateTranslation(ctx context.Context, songID uint, prefs []language.Tag) (*models.SongTranslation, error) {
+	if len(prefs) == 0 {
+		return nil, nil
+	}
+	translations, err := s.repo.GetTranslations(ctx, songID)
+	if err != nil || len(translations) == 0 {
+		return nil, err
+	}
+	// оригинал стоит первым: при отсутствии подходящего перевода matcher возвращает его
+	supported := []language.Tag{language.Und}
+	for _, t := range translations {
+		supported = append(supported, language.Make(t.Language))
+	}
+	_, idx, confidence := language.NewMatcher(supported).Match(prefs...)
+	if idx == 0 || confidence == language.No {
+		return nil, nil
+	}
+	s.log(ctx).Debug("translation negotiated",
+		zap.Uint("song_id", songID),
+		zap.String("language", translations[idx-1].Language),
+		zap.String("confidence", confidence.String()))
+	return &translations[idx-1], nil
+}
@@ -0,0 +1,52 @@
+package fakeinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SongDetail ответ /info по схеме SongDetail из описания Music info API
+type SongDetail struct {
+	ReleaseDate string `json:"releaseDate" yaml:"releaseDate"`
+	Text        string `json:"text" yaml:"text"`
+	Link        string `json:"link" yaml:"link"`
+}
+
+// Fixture песня, которую отдает фейковый сервер
+type Fixture struct {
+	Group      string `json:"group" yaml:"group"`
+	Song       string `json:"song" yaml:"song"`
+	SongDetail `yaml:",inline"`
+}
+
+// LoadFixtures читает фикстуры из json- или yaml-файла со списком песен
+func LoadFixtures(path string) ([]Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []Fixture
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(data, &fixtures)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fixtures)
+	default:
+		return nil, fmt.Errorf("unsupported fixtures format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures %s: %w", path, err)
+	}
+
+	for i, f := range fixtures {
+		if f.Group == "" || f.Song == "" {
+			return nil, fmt.Errorf("fixture #%d in %s: group and song are required", i, path)
+		}
+	}
+	return fixtures, nil
+}
@@ -0,0 +1,140 @@
+package fakeinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode режим ответа фейкового сервера
+type Mode string
+
+const (
+	ModeOK         Mode = "ok"
+	ModeBadRequest Mode = "bad_request"
+	ModeError      Mode = "error"
+	ModeMalformed  Mode = "malformed"
+)
+
+// Options управляют симуляцией сбоев внешнего API
+type Options struct {
+	// Mode отвечать всегда 400, 500 или невалидным телом вместо данных из фикстур
+	Mode Mode
+	// Latency задержка перед каждым ответом
+	Latency time.Duration
+	// FailureRate доля запросов в режиме ok, на которые отвечать 500
+	FailureRate float64
+}
+
+// Server реализует GET /info из описания Music info API по данным из фикстур.
+// Для неизвестной песни отвечает 404
+type Server struct {
+	mu    sync.RWMutex
+	songs map[string]SongDetail
+	opts  Options
+}
+
+func New(fixtures []Fixture, opts Options) *Server {
+	s := &Server{songs: make(map[string]SongDetail, len(fixtures))}
+	for _, f := range fixtures {
+		s.songs[songKey(f.Group, f.Song)] = f.SongDetail
+	}
+	s.SetOptions(opts)
+	return s
+}
+
+// NewTestServer запускает фейковый сервер на локальном адресе, путь /info доступен по URL + "/info"
+func NewTestServer(fixtures []Fixture, opts Options) (*Server, *httptest.Server) {
+	s := New(fixtures, opts)
+	mux := http.NewServeMux()
+	mux.Handle("/info", s)
+	return s, httptest.NewServer(mux)
+}
+
+// SetOptions меняет режим работы сервера на лету
+func (s *Server) SetOptions(opts Options) {
+	if opts.Mode == "" {
+		opts.Mode = ModeOK
+	}
+	s.mu.Lock()
+	s.opts = opts
+	s.mu.Unlock()
+}
+
+// Add добавляет или заменяет песню
+func (s *Server) Add(f Fixture) {
+	s.mu.Lock()
+	s.songs[songKey(f.Group, f.Song)] = f.SongDetail
+	s.mu.Unlock()
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	opts := s.opts
+	s.mu.RUnlock()
+
+	if opts.Latency > 0 {
+		select {
+		case <-time.After(opts.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	switch opts.Mode {
+	case ModeBadRequest:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	case ModeError:
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if opts.FailureRate > 0 && rand.Float64() < opts.FailureRate {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	group := r.URL.Query().Get("group")
+	song := r.URL.Query().Get("song")
+	if group == "" || song == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.RLock()
+	detail, ok := s.songs[songKey(group, song)]
+	s.mu.RUnlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if opts.Mode == ModeMalformed {
+		_, _ = w.Write([]byte(`{"releaseDate": "16.07.2006", "text": `))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(detail)
+}
+
+func songKey(group, song string) string {
+	return strings.ToLower(group) + "\x00" + strings.ToLower(song)
+}
+
+// ParseMode проверяет название режима
+func ParseMode(mode string) (Mode, error) {
+	switch m := Mode(mode); m {
+	case ModeOK, ModeBadRequest, ModeError, ModeMalformed:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown mode %q", mode)
+}
@@ -1,16 +1,13 @@
 package logger
 
 import (
+	"context"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"time"
 )
 
-//const Key = "logger"
-
-//type Logger struct {
-//	l *zap.Logger
-//}
+type ctxKey struct{}
 
 func New(logLevel string) (*zap.Logger, error) {
 	config := zap.NewProductionConfig()
@@ -38,18 +35,21 @@ func New(logLevel string) (*zap.Logger, error) {
 	return logger, err
 }
 
-//func GetLoggerFromCtx(ctx context.Context) *Logger {
-//	return ctx.Value(Key)
-//}
-
-//func (l *Logger) Info(msg string, fields ...zap.Field) {
-//	l.l.Info(msg, fields...)
-//}
-//
-//func (l *Logger) Fatal(msg string, fields ...zap.Field) {
-//	l.l.Fatal(msg, fields...)
-//}
-//
-//func (l *Logger) Debug(msg string, fields ...zap.Field) {
-//	l.l.Debug(msg, fields...)
-//}
+// WithContext кладет логгер запроса в контекст
+func WithContext(ctx context.Context, l *zap.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext возвращает логгер запроса из контекста
+func FromContext(ctx context.Context) (*zap.Logger, bool) {
+	l, ok := ctx.Value(ctxKey{}).(*zap.Logger)
+	return l, ok
+}
+
+// FromContextOr возвращает логгер запроса из контекста, а если его нет — fallback
+func FromContextOr(ctx context.Context, fallback *zap.Logger) *zap.Logger {
+	if l, ok := FromContext(ctx); ok {
+		return l
+	}
+	return fallback
+}
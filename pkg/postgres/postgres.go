@@ -1,13 +1,16 @@
 package postgres
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"os"
 )
 
 type Config struct {
@@ -55,3 +58,57 @@ func runMigrations(databaseURL, migrationsPath string) error {
 
 	return nil
 }
+
+// Ping проверяет подключение к базе данных
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// LatestMigrationVersion возвращает номер последней миграции в каталоге migrationsPath
+func LatestMigrationVersion(migrationsPath string) (uint, error) {
+	src, err := source.Open(migrationsPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
+
+// CheckMigrationVersion проверяет, что схема БД находится на версии expected и последняя миграция не упала
+func CheckMigrationVersion(ctx context.Context, db *gorm.DB, expected uint) error {
+	var row struct {
+		Version uint
+		Dirty   bool
+	}
+	err := db.WithContext(ctx).
+		Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").
+		Scan(&row).Error
+	if err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+	if row.Dirty {
+		return fmt.Errorf("migration %d is dirty", row.Version)
+	}
+	if row.Version != expected {
+		return fmt.Errorf("migration version %d, expected %d", row.Version, expected)
+	}
+	return nil
+}